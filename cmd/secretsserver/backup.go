@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tomek7667/secrets/internal/backup"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+// backupPassphrase prefers the passphrase file over SECRETS_BACKUP_PASSPHRASE, so that it doesn't end up in the shell history
+func backupPassphrase(opts *CliOptions, passphraseFile string) (string, error) {
+	if passphraseFile == "" {
+		return opts.BackupPassphrase, nil
+	}
+	b, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the passphrase file: %w", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// checkSqlite refuses a --db-url, backups are sqlite snapshots
+func checkSqlite(opts *CliOptions) error {
+	if opts.DbUrl != "" {
+		return fmt.Errorf("backup and restore work on sqlite only, back up postgres with its own tools, e.g. pg_dump")
+	}
+	return nil
+}
+
+func newBackupCmd(opts *CliOptions) *cobra.Command {
+	var passphraseFile string
+	var recipients []string
+	cmd := &cobra.Command{
+		Use:   "backup <file>",
+		Short: "Write an encrypted snapshot of the database, safe to run next to the server (- for stdout)",
+		Args:  cobra.ExactArgs(1),
+		// encryption errors are not usage errors
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkSqlite(opts); err != nil {
+				return err
+			}
+			passphrase, err := backupPassphrase(opts, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if len(recipients) == 0 {
+				recipients = opts.BackupRecipients
+			}
+			r, err := backup.Recipients(passphrase, recipients)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(opts.DbPath); err != nil {
+				return fmt.Errorf("no database at '%s': %w", opts.DbPath, err)
+			}
+			ctx := context.Background()
+			// a backup doesn't migrate, the database may belong to a running server of another version
+			c, err := sqlite.Open(ctx, opts.DbPath, storageConfig(opts).Sqlite)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			var w io.Writer = os.Stdout
+			if args[0] != "-" {
+				f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+				if err != nil {
+					return fmt.Errorf("failed to create '%s': %w", args[0], err)
+				}
+				defer f.Close()
+				w = f
+			}
+			if err := backup.Write(ctx, c.DB, w, r...); err != nil {
+				if args[0] != "-" {
+					os.Remove(args[0])
+				}
+				return err
+			}
+			if args[0] != "-" {
+				fmt.Fprintf(os.Stderr, "backup written to %s\n", args[0])
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "file with the passphrase to encrypt with (default $SECRETS_BACKUP_PASSPHRASE)")
+	cmd.Flags().StringSliceVar(&recipients, "recipient", nil, "age public key (age1...) to encrypt to instead of a passphrase, can be repeated (default $SECRETS_BACKUP_RECIPIENTS)")
+	return cmd
+}
+
+func newRestoreCmd(opts *CliOptions) *cobra.Command {
+	var passphraseFile string
+	var identityFile string
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Verify an encrypted backup and replace the database with it, the server must be stopped",
+		Args:  cobra.ExactArgs(1),
+		// decryption and verification errors are not usage errors
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkSqlite(opts); err != nil {
+				return err
+			}
+			var identity []byte
+			if identityFile != "" {
+				b, err := os.ReadFile(identityFile)
+				if err != nil {
+					return fmt.Errorf("failed to read the identity file: %w", err)
+				}
+				identity = b
+			}
+			passphrase := ""
+			if identity == nil {
+				p, err := backupPassphrase(opts, passphraseFile)
+				if err != nil {
+					return err
+				}
+				passphrase = p
+			}
+			identities, err := backup.Identities(passphrase, identity)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(opts.DbPath); err == nil && !force {
+				return fmt.Errorf("'%s' already exists, use --force to replace it", opts.DbPath)
+			}
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open '%s': %w", args[0], err)
+			}
+			defer f.Close()
+			if err := backup.Restore(context.Background(), f, opts.DbPath, identities...); err != nil {
+				return err
+			}
+			fmt.Printf("restored %s from %s\n", opts.DbPath, args[0])
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "file with the passphrase the backup was encrypted with (default $SECRETS_BACKUP_PASSPHRASE)")
+	cmd.Flags().StringVar(&identityFile, "identity", "", "age identity file (AGE-SECRET-KEY-1...) for backups encrypted to a recipient")
+	cmd.Flags().BoolVar(&force, "force", false, "replace an existing database")
+	return cmd
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/cluster"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+// clusterStartTimeout is how long a new node waits to join the cluster and to catch up with its log
+const clusterStartTimeout = time.Minute
+
+// openCluster opens the sqlite db as a node of the cluster, whose writes go through raft
+func openCluster(ctx context.Context, opts *CliOptions) (*cluster.Node, error) {
+	if opts.DbUrl != "" {
+		return nil, fmt.Errorf("cluster nodes replicate a sqlite db, --cluster-id can't be used with --db-url")
+	}
+	if opts.ReplicateFrom != "" || opts.ReplicationSecret != "" {
+		return nil, fmt.Errorf("cluster nodes replicate through raft, --cluster-id can't be used with --replicate-from or --replication-secret")
+	}
+	if opts.ClusterSecret == "" {
+		return nil, fmt.Errorf("--cluster-id needs --cluster-secret")
+	}
+	db, err := sqlite.New(ctx, opts.DbPath, storageConfig(opts).Sqlite)
+	if err != nil {
+		return nil, err
+	}
+	url := opts.ClusterUrl
+	if url == "" {
+		url = "http://" + opts.Address
+	}
+	dir := opts.ClusterDir
+	if dir == "" {
+		dir = opts.DbPath + ".raft"
+	}
+	ctx, cancel := context.WithTimeout(ctx, clusterStartTimeout)
+	defer cancel()
+	node, err := cluster.Open(ctx, db, cluster.Config{
+		ID:        opts.ClusterId,
+		Address:   opts.ClusterAddress,
+		Url:       url,
+		Dir:       dir,
+		Secret:    opts.ClusterSecret,
+		Bootstrap: opts.ClusterBootstrap,
+		Join:      opts.ClusterJoin,
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to start the cluster node: %w", err)
+	}
+	return node, nil
+}
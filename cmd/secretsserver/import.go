@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tomek7667/secrets/internal/secretsio"
+	"github.com/tomek7667/secrets/internal/storage"
+)
+
+type importOptions struct {
+	Format   string
+	Conflict string
+	Prefix   string
+	DryRun   bool
+}
+
+// newImportCmd imports secrets straight into the database, watchers and webhooks of a running server are not notified
+func newImportCmd(opts *CliOptions) *cobra.Command {
+	var importOpts importOptions
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import secrets from a .env, json or yaml file",
+		Args:  cobra.ExactArgs(1),
+		// conflicts are not usage errors
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := secretsio.Format(importOpts.Format)
+			if format == "" {
+				detected, err := secretsio.DetectFormat(args[0])
+				if err != nil {
+					return err
+				}
+				format = detected
+			}
+			policy, err := secretsio.ParseConflictPolicy(importOpts.Conflict)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read '%s': %w", args[0], err)
+			}
+			values, err := secretsio.Parse(format, data)
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			c, err := storage.New(ctx, storageConfig(opts))
+			if err != nil {
+				return fmt.Errorf("failed to initialize the db: %w", err)
+			}
+			defer c.Close()
+			changes, err := secretsio.Import(ctx, c, secretsio.WithPrefix(values, importOpts.Prefix), policy, importOpts.DryRun)
+			if err != nil {
+				return err
+			}
+			for _, change := range changes {
+				fmt.Printf("%-10s %s\n", change.Action, change.Key)
+			}
+			if importOpts.DryRun {
+				fmt.Println("dry run, nothing was written")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&importOpts.Format, "format", "", "file format: env, json or yaml (detected from the file name by default)")
+	cmd.Flags().StringVar(&importOpts.Conflict, "conflict", string(secretsio.FailPolicy), "what to do with existing secrets that differ: skip, overwrite or fail")
+	cmd.Flags().StringVar(&importOpts.Prefix, "prefix", "", "prepended to every imported key, e.g. app/")
+	cmd.Flags().BoolVar(&importOpts.DryRun, "dry-run", false, "only print what would change")
+	return cmd
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tomek7667/secrets/internal/storage"
+)
+
+// newMigrateCmd manages the schema by hand, the server applies pending migrations on startup anyway
+func newMigrateCmd(opts *CliOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply, roll back or list the database migrations",
+	}
+	open := func() (storage.Store, error) {
+		if opts.DbUrl == "" {
+			if _, err := os.Stat(opts.DbPath); err != nil {
+				return nil, fmt.Errorf("no database at '%s': %w", opts.DbPath, err)
+			}
+		}
+		return storage.Open(context.Background(), storageConfig(opts))
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:          "up",
+		Short:        "Apply the pending migrations, creating the database when it doesn't exist",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := storage.Open(context.Background(), storageConfig(opts))
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			results, err := c.Migrate(context.Background())
+			for _, result := range results {
+				fmt.Printf("applied %s (%s)\n", filepath.Base(result.Source.Path), result.Duration)
+			}
+			if err != nil {
+				return err
+			}
+			if len(results) == 0 {
+				fmt.Println("no pending migrations")
+			}
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:          "down",
+		Short:        "Roll back the last applied migration",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := open()
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			result, err := c.MigrateDown(context.Background())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("rolled back %s (%s)\n", filepath.Base(result.Source.Path), result.Duration)
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:          "status",
+		Short:        "List the migrations and whether they are applied",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := open()
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			statuses, err := c.MigrationStatus(context.Background())
+			if err != nil {
+				return err
+			}
+			for _, status := range statuses {
+				appliedAt := "pending"
+				if !status.AppliedAt.IsZero() {
+					appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+				}
+				fmt.Printf("%-20s %s\n", appliedAt, filepath.Base(status.Source.Path))
+			}
+			return nil
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tomek7667/secrets/internal/replication"
+	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlite"
+	"github.com/tomek7667/secrets/internal/storage"
+)
+
+// openDb opens the db of the server, which is a replica downloaded from the primary on followers
+func openDb(ctx context.Context, opts *CliOptions) (storage.Store, *replication.Follower, error) {
+	if opts.ClusterId != "" {
+		node, err := openCluster(ctx, opts)
+		return node, nil, err
+	}
+	if opts.ReplicateFrom == "" {
+		db, err := storage.New(ctx, storageConfig(opts))
+		return db, nil, err
+	}
+	if opts.ReplicationSecret == "" {
+		return nil, nil, fmt.Errorf("--replicate-from needs --replication-secret")
+	}
+	if opts.DbUrl != "" {
+		return nil, nil, fmt.Errorf("followers keep a sqlite replica, --replicate-from can't be used with --db-url")
+	}
+	id := opts.ReplicationId
+	if id == "" {
+		hostname, _ := os.Hostname()
+		id = hostname + "/" + opts.Address
+	}
+	follower, err := replication.NewFollower(ctx, opts.ReplicateFrom, opts.ReplicationSecret, id, opts.DbPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to replicate %s: %w", opts.ReplicateFrom, err)
+	}
+	db, err := sqlite.OpenReplica(ctx, opts.DbPath, storageConfig(opts).Sqlite)
+	if err != nil {
+		follower.Close()
+		return nil, nil, err
+	}
+	return db, follower, nil
+}
+
+func enableReplication(srv *secrets.Server, opts CliOptions, follower *replication.Follower) error {
+	if opts.ReplicationSecret == "" {
+		return nil
+	}
+	return srv.EnableReplication(secrets.ReplicationConfig{
+		Secret:   opts.ReplicationSecret,
+		Follower: follower,
+	})
+}
+
+// newPromoteCmd makes a running follower the primary, the old primary must be stopped first
+func newPromoteCmd(opts *CliOptions) *cobra.Command {
+	var follower string
+	cmd := &cobra.Command{
+		Use:          "promote",
+		Short:        "Make a running follower the primary, e.g. after the primary has failed",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.ReplicationSecret == "" {
+				return fmt.Errorf("--replication-secret is required")
+			}
+			req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(follower, "/")+"/api/replication/promote", nil)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", replication.AuthScheme+" "+opts.ReplicationSecret)
+			client := &http.Client{Timeout: time.Minute}
+			res, err := client.Do(req)
+			if err != nil {
+				return fmt.Errorf("failed to reach the follower: %w", err)
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				return err
+			}
+			if res.StatusCode != http.StatusOK {
+				return fmt.Errorf("the follower responded with %s: %s", res.Status, body)
+			}
+			var promoted struct {
+				Data replication.PrimaryStatus `json:"data"`
+			}
+			if err := json.Unmarshal(body, &promoted); err != nil {
+				return err
+			}
+			fmt.Printf("promoted %s, it's the primary of generation %s\n", follower, promoted.Data.Position.Generation)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&follower, "follower", "http://127.0.0.1:7770", "url of the follower to promote")
+	return cmd
+}
@@ -0,0 +1,30 @@
+package audit
+
+import "time"
+
+type Severity int
+
+// Values follow the RFC 5424 severity codes so sinks can use them as is.
+const (
+	SeverityError   Severity = 3
+	SeverityWarning Severity = 4
+	SeverityInfo    Severity = 6
+)
+
+type Entry struct {
+	ID           string    `json:"id"`
+	Time         time.Time `json:"time"`
+	Event        string    `json:"event"`
+	Severity     Severity  `json:"severity"`
+	Msg          string    `json:"msg"`
+	RequestedUrl string    `json:"requested_url,omitempty"`
+	RemoteAddr   string    `json:"remote_addr,omitempty"`
+}
+
+// Sink receives every audit entry after it was saved to the database.
+// Implementations must be safe for concurrent use.
+type Sink interface {
+	Name() string
+	Write(e Entry) error
+	Close() error
+}
@@ -0,0 +1,165 @@
+package audit_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/audit"
+)
+
+func testEntry() audit.Entry {
+	return audit.Entry{
+		ID:           "d4a1c5e2",
+		Time:         time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Event:        "login-failed",
+		Severity:     audit.SeverityWarning,
+		Msg:          "GetUserByUsername for admin",
+		RequestedUrl: "POST /login",
+		RemoteAddr:   "127.0.0.1:5555",
+	}
+}
+
+func TestSyslogSinkUdp(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	sink, err := audit.NewSyslogSink("udp://" + pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Write(testEntry()); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 2048)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	// facility 13 (log audit) * 8 + severity 4 (warning)
+	if !strings.HasPrefix(msg, "<108>1 2026-01-02T03:04:05.000000Z ") {
+		t.Errorf("unexpected syslog header: %s", msg)
+	}
+	if !strings.Contains(msg, ` login-failed [audit@32473 id="d4a1c5e2" url="POST /login" remote="127.0.0.1:5555"] GetUserByUsername for admin`) {
+		t.Errorf("unexpected syslog message: %s", msg)
+	}
+}
+
+func TestSyslogSinkTcpOctetCounting(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		length, _ := r.ReadString(' ')
+		n, _ := strconv.Atoi(strings.TrimSpace(length))
+		msg := make([]byte, n)
+		io.ReadFull(r, msg)
+		received <- string(msg)
+	}()
+
+	sink, err := audit.NewSyslogSink("tcp://" + l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Write(testEntry()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-received:
+		if !strings.HasPrefix(msg, "<108>1 ") || !strings.HasSuffix(msg, "GetUserByUsername for admin") {
+			t.Errorf("frame was not read whole: %s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("syslog message not received")
+	}
+}
+
+func TestWebhookSinkRetries(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan audit.Entry, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var e audit.Entry
+		json.NewDecoder(r.Body).Decode(&e)
+		received <- e
+	}))
+	defer srv.Close()
+
+	sink := audit.NewWebhookSink(srv.URL, 5, time.Millisecond)
+	if err := sink.Write(testEntry()); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close()
+
+	select {
+	case e := <-received:
+		if e.ID != testEntry().ID {
+			t.Errorf("unexpected entry id %s", e.ID)
+		}
+	default:
+		t.Fatal("webhook did not deliver the entry")
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts.Load())
+	}
+}
+
+func TestFileSinkJsonLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := audit.NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Write(testEntry())
+	sink.Write(testEntry())
+	sink.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var e audit.Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Errorf("line is not valid json: %s", line)
+		}
+		if e.Event != "login-failed" {
+			t.Errorf("unexpected event %s", e.Event)
+		}
+	}
+}
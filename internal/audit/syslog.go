@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// facility 13 is "log audit" in RFC 5424
+	syslogFacility = 13
+	syslogAppName  = "secretsserver"
+	// 32473 is the private enterprise number reserved for documentation
+	syslogSdId = "audit@32473"
+)
+
+type SyslogSink struct {
+	network  string
+	address  string
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink sends RFC 5424 messages to rawUrl, e.g. udp://127.0.0.1:514
+// or tcp://siem.local:601. TCP messages use octet-counting framing.
+func NewSyslogSink(rawUrl string) (*SyslogSink, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse syslog url '%s': %w", rawUrl, err)
+	}
+	if u.Scheme != "udp" && u.Scheme != "tcp" {
+		return nil, fmt.Errorf("unsupported syslog network '%s', expected udp or tcp", u.Scheme)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	s := &SyslogSink{
+		network:  u.Scheme,
+		address:  u.Host,
+		hostname: hostname,
+	}
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SyslogSink) Name() string {
+	return "syslog " + s.network + "://" + s.address
+}
+
+func (s *SyslogSink) dial() error {
+	conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to dial syslog %s://%s: %w", s.network, s.address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *SyslogSink) Write(e Entry) error {
+	msg := s.format(e)
+	if s.network == "tcp" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if err := s.dial(); err != nil {
+			return err
+		}
+	}
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		// the collector may have been restarted, so reconnect once
+		s.conn.Close()
+		s.conn = nil
+		if err := s.dial(); err != nil {
+			return err
+		}
+		if _, err := s.conn.Write([]byte(msg)); err != nil {
+			return fmt.Errorf("failed to write to syslog %s://%s: %w", s.network, s.address, err)
+		}
+	}
+	return nil
+}
+
+func (s *SyslogSink) format(e Entry) string {
+	pri := syslogFacility*8 + int(e.Severity)
+	sd := fmt.Sprintf(
+		`[%s id="%s" url="%s" remote="%s"]`,
+		syslogSdId,
+		escapeSdValue(e.ID),
+		escapeSdValue(e.RequestedUrl),
+		escapeSdValue(e.RemoteAddr),
+	)
+	return fmt.Sprintf(
+		"<%d>1 %s %s %s %d %s %s %s",
+		pri,
+		e.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname,
+		syslogAppName,
+		os.Getpid(),
+		headerField(e.Event),
+		sd,
+		e.Msg,
+	)
+}
+
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// escapeSdValue escapes characters that are special inside of a structured data param value
+func escapeSdValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
+
+// headerField makes sure the value is a valid header field: printable ascii without spaces
+func headerField(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, v)
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const webhookQueueSize = 1024
+
+type WebhookSink struct {
+	url        string
+	maxRetries int
+	baseDelay  time.Duration
+	client     *http.Client
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan Entry
+	wg     sync.WaitGroup
+}
+
+// NewWebhookSink posts every entry as json to url. Deliveries happen in the
+// background and failed ones are retried maxRetries times with exponential
+// backoff starting at baseDelay.
+func NewWebhookSink(url string, maxRetries int, baseDelay time.Duration) *WebhookSink {
+	s := &WebhookSink{
+		url:        url,
+		maxRetries: maxRetries,
+		baseDelay:  baseDelay,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		queue:      make(chan Entry, webhookQueueSize),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook " + s.url
+}
+
+func (s *WebhookSink) Write(e Entry) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return fmt.Errorf("webhook sink is closed")
+	}
+	select {
+	case s.queue <- e:
+		return nil
+	default:
+		return fmt.Errorf("webhook queue is full, dropping entry %s", e.ID)
+	}
+}
+
+func (s *WebhookSink) run() {
+	defer s.wg.Done()
+	for e := range s.queue {
+		if err := s.deliver(e); err != nil {
+			slog.Error(
+				"failed to deliver audit entry to webhook",
+				"err", err,
+				"url", s.url,
+				"id", e.ID,
+			)
+		}
+	}
+}
+
+func (s *WebhookSink) deliver(e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	delay := s.baseDelay
+	for attempt := 0; ; attempt++ {
+		err = s.post(b)
+		if err == nil {
+			return nil
+		}
+		if attempt >= s.maxRetries {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt+1, err)
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (s *WebhookSink) post(body []byte) error {
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close stops accepting entries and waits until the queued ones are delivered.
+func (s *WebhookSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
@@ -0,0 +1,158 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Recipients returns who a backup is encrypted to, either a passphrase or age public keys ("age1..."), not both
+func Recipients(passphrase string, publicKeys []string) ([]age.Recipient, error) {
+	if passphrase != "" && len(publicKeys) > 0 {
+		return nil, fmt.Errorf("a backup is encrypted either with a passphrase or to recipients, not both")
+	}
+	if passphrase != "" {
+		r, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{r}, nil
+	}
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("a passphrase or a recipient is required to encrypt the backup")
+	}
+	recipients := make([]age.Recipient, 0, len(publicKeys))
+	for _, key := range publicKeys {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient '%s': %w", key, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// Identities returns what decrypts a backup, a passphrase or the content of an age identity file ("AGE-SECRET-KEY-1...")
+func Identities(passphrase string, identityFile []byte) ([]age.Identity, error) {
+	if passphrase != "" {
+		i, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{i}, nil
+	}
+	if len(identityFile) == 0 {
+		return nil, fmt.Errorf("a passphrase or an identity is required to decrypt the backup")
+	}
+	identities, err := age.ParseIdentities(strings.NewReader(string(identityFile)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity file: %w", err)
+	}
+	return identities, nil
+}
+
+// Snapshot copies the database to path with VACUUM INTO, which is consistent while the server keeps writing
+func Snapshot(ctx context.Context, db *sql.DB, path string) error {
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to snapshot the database: %w", err)
+	}
+	return nil
+}
+
+// Write snapshots the database and writes it encrypted to w. Nothing is written when the snapshot fails.
+func Write(ctx context.Context, db *sql.DB, w io.Writer, recipients ...age.Recipient) error {
+	dir, err := os.MkdirTemp("", "secrets-backup-*")
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	snapshot := filepath.Join(dir, "secrets.sqlite")
+	if err := Snapshot(ctx, db, snapshot); err != nil {
+		return err
+	}
+	f, err := os.Open(snapshot)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encrypted, err := age.Encrypt(w, recipients...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt the backup: %w", err)
+	}
+	if _, err := io.Copy(encrypted, f); err != nil {
+		return fmt.Errorf("failed to write the backup: %w", err)
+	}
+	if err := encrypted.Close(); err != nil {
+		return fmt.Errorf("failed to write the backup: %w", err)
+	}
+	return nil
+}
+
+// Restore decrypts the backup, verifies it and only then replaces the database at dbPath.
+// The server must not be running on dbPath.
+func Restore(ctx context.Context, r io.Reader, dbPath string, identities ...age.Identity) error {
+	decrypted, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt the backup, wrong passphrase or identity: %w", err)
+	}
+	// next to the database, so that the rename is atomic
+	tmp, err := os.CreateTemp(filepath.Dir(dbPath), ".secrets-restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	// age authenticates every chunk, so a modified backup fails here
+	if _, err := io.Copy(tmp, decrypted); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to decrypt the backup: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := Verify(ctx, tmp.Name()); err != nil {
+		return err
+	}
+	// a journal left by the replaced database would be applied to the restored one
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove '%s': %w", dbPath+suffix, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), dbPath); err != nil {
+		return fmt.Errorf("failed to replace the database: %w", err)
+	}
+	return nil
+}
+
+// Verify checks that the file is an intact secrets database
+func Verify(ctx context.Context, path string) error {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("backup is not a sqlite database: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup failed the integrity check: %s", result)
+	}
+	var tables int
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'secret'").Scan(&tables); err != nil {
+		return err
+	}
+	if tables == 0 {
+		return fmt.Errorf("backup is not a secrets database")
+	}
+	return nil
+}
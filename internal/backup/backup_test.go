@@ -0,0 +1,109 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/tomek7667/secrets/internal/backup"
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c, err := sqlite.New(ctx, filepath.Join(dir, "source.sqlite"), sqlite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Queries.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "1", Key: "db/password", Value: "czNjcjN0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipients, err := backup.Recipients("", []string{identity.Recipient().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encrypted bytes.Buffer
+	if err := backup.Write(ctx, c.DB, &encrypted, recipients...); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := filepath.Join(dir, "restored.sqlite")
+	if err := backup.Restore(ctx, bytes.NewReader(encrypted.Bytes()), restored, identity); err != nil {
+		t.Fatal(err)
+	}
+	r, err := sqlite.New(ctx, restored, sqlite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	secret, err := r.Queries.GetSecret(ctx, "db/password")
+	if err != nil || secret.Value != "czNjcjN0" {
+		t.Errorf("expected the secret to be restored, got %v (%v)", secret, err)
+	}
+
+	other, _ := age.GenerateX25519Identity()
+	if err := backup.Restore(ctx, bytes.NewReader(encrypted.Bytes()), filepath.Join(dir, "other.sqlite"), other); err == nil {
+		t.Error("expected a wrong identity to fail")
+	}
+	tampered := bytes.Clone(encrypted.Bytes())
+	tampered[len(tampered)-20] ^= 1
+	if err := backup.Restore(ctx, bytes.NewReader(tampered), filepath.Join(dir, "tampered.sqlite"), identity); err == nil {
+		t.Error("expected a tampered backup to fail")
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	if _, err := backup.Recipients("pass", []string{"age1..."}); err == nil {
+		t.Error("expected a passphrase together with recipients to fail")
+	}
+	if _, err := backup.Recipients("", nil); err == nil {
+		t.Error("expected no passphrase and no recipients to fail")
+	}
+	if _, err := backup.Recipients("", []string{"not a key"}); err == nil {
+		t.Error("expected an invalid recipient to fail")
+	}
+}
+
+func TestRetentionExpired(t *testing.T) {
+	var files []backup.File
+	// two backups a day for 60 days, newest first
+	start := time.Date(2026, 3, 31, 15, 0, 0, 0, time.UTC)
+	for i := range 120 {
+		createdAt := start.Add(-time.Duration(i) * 12 * time.Hour)
+		files = append(files, backup.File{Name: backup.FileName(createdAt), CreatedAt: createdAt})
+	}
+	expired := backup.Retention{Daily: 7, Weekly: 4, Monthly: 3}.Expired(files)
+	kept := map[string]bool{}
+	for _, file := range files {
+		kept[file.Name] = true
+	}
+	for _, file := range expired {
+		delete(kept, file.Name)
+	}
+
+	for _, expected := range []time.Time{
+		start, // newest
+		time.Date(2026, 3, 25, 15, 0, 0, 0, time.UTC), // 7th day
+		time.Date(2026, 3, 22, 15, 0, 0, 0, time.UTC), // sunday ending an older week
+		time.Date(2026, 2, 28, 15, 0, 0, 0, time.UTC), // end of february
+	} {
+		if !kept[backup.FileName(expected)] {
+			t.Errorf("expected %s to be kept", expected)
+		}
+	}
+	// 7 days, the weeks ending 22 and 15 march (the last two weeks end within the days), february and january
+	if len(kept) != 11 {
+		t.Errorf("expected 11 backups to be kept, got %d: %v", len(kept), kept)
+	}
+}
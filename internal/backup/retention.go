@@ -0,0 +1,144 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+const (
+	filePrefix     = "secrets-"
+	fileSuffix     = ".sqlite.age"
+	fileTimeLayout = "20060102-150405"
+)
+
+type File struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// Retention keeps the newest backup of each of the last Daily days, Weekly weeks and Monthly months
+type Retention struct {
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+// FileName of a backup taken at t, e.g. "secrets-20260102-030000.sqlite.age"
+func FileName(t time.Time) string {
+	return filePrefix + t.UTC().Format(fileTimeLayout) + fileSuffix
+}
+
+// List returns the backups in dir newest first, other files are ignored
+func List(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the backup directory: %w", err)
+	}
+	var files []File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		createdAt, err := time.Parse(fileTimeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: name, CreatedAt: createdAt, Size: info.Size()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.After(files[j].CreatedAt) })
+	return files, nil
+}
+
+// WriteFile writes a backup into dir. It's written under a temporary name first, so that
+// a failed backup never shows up in List.
+func WriteFile(ctx context.Context, db *sql.DB, dir string, now time.Time, recipients ...age.Recipient) (File, error) {
+	name := FileName(now)
+	tmp, err := os.CreateTemp(dir, "."+name+"-*")
+	if err != nil {
+		return File{}, fmt.Errorf("failed to create the backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := Write(ctx, db, tmp, recipients...); err != nil {
+		tmp.Close()
+		return File{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return File{}, fmt.Errorf("failed to write the backup: %w", err)
+	}
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return File{}, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return File{}, fmt.Errorf("failed to rename the backup: %w", err)
+	}
+	return File{Name: name, CreatedAt: now.UTC().Truncate(time.Second), Size: info.Size()}, nil
+}
+
+// Expired returns the files, sorted newest first, that the retention doesn't keep. The newest backup is always kept.
+func (r Retention) Expired(files []File) []File {
+	if len(files) == 0 {
+		return nil
+	}
+	keep := map[string]bool{files[0].Name: true}
+	periods := []struct {
+		limit  int
+		period func(t time.Time) string
+	}{
+		{r.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{r.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%02d", year, week)
+		}},
+		{r.Monthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, p := range periods {
+		seen := map[string]bool{}
+		for _, file := range files {
+			if len(seen) >= p.limit {
+				break
+			}
+			period := p.period(file.CreatedAt.UTC())
+			if !seen[period] {
+				seen[period] = true
+				keep[file.Name] = true
+			}
+		}
+	}
+	var expired []File
+	for _, file := range files {
+		if !keep[file.Name] {
+			expired = append(expired, file)
+		}
+	}
+	return expired
+}
+
+// Prune deletes the backups in dir that the retention doesn't keep
+func Prune(dir string, r Retention) ([]File, error) {
+	files, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	expired := r.Expired(files)
+	for _, file := range expired {
+		if err := os.Remove(filepath.Join(dir, file.Name)); err != nil {
+			return nil, fmt.Errorf("failed to delete backup %s: %w", file.Name, err)
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,50 @@
+// Package buildinfo tells which version of the module a binary was built from
+package buildinfo
+
+import "runtime/debug"
+
+// Module is the path of the server and the sdk module
+const Module = "github.com/tomek7667/secrets"
+
+// Devel is the version of the builds without one, e.g. go run and go test
+const Devel = "(devel)"
+
+type Info struct {
+	// Version is the module version, a tag like v1.2.0 or a pseudo-version of the commit for go build in the repo
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// Read is the build info of the running binary, the module is either the main one (secretsserver)
+// or a dependency (an application using the sdk)
+func Read() Info {
+	info := Info{Version: Devel}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = build.GoVersion
+	module := &build.Main
+	for _, dep := range build.Deps {
+		if dep.Path == Module {
+			module = dep
+		}
+	}
+	if module.Path == Module && module.Version != "" {
+		info.Version = module.Version
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
@@ -0,0 +1,204 @@
+package cluster_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/cluster"
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+// testNode mirrors the cluster routes of the secrets server, which forwards requests to the leader
+type testNode struct {
+	*cluster.Node
+	srv *httptest.Server
+}
+
+func respond(w http.ResponseWriter, data any, err error) {
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{"message": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func startNode(t *testing.T, ctx context.Context, dir, id string, join string) *testNode {
+	node := &testNode{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/cluster/join", func(w http.ResponseWriter, r *http.Request) {
+		var dto struct{ ID, Address string }
+		json.NewDecoder(r.Body).Decode(&dto)
+		index, err := node.Join(dto.ID, dto.Address)
+		respond(w, map[string]uint64{"index": index}, err)
+	})
+	mux.HandleFunc("POST /api/cluster/apply", func(w http.ResponseWriter, r *http.Request) {
+		var cmd struct{ Statements []sqlite.Statement }
+		json.NewDecoder(r.Body).Decode(&cmd)
+		respond(w, nil, node.Apply(r.Context(), cmd.Statements))
+	})
+	node.srv = httptest.NewServer(mux)
+	t.Cleanup(node.srv.Close)
+
+	db, err := sqlite.New(ctx, filepath.Join(dir, id+".sqlite"), sqlite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Node, err = cluster.Open(ctx, db, cluster.Config{
+		ID:        id,
+		Address:   freeAddress(t),
+		Url:       node.srv.URL,
+		Dir:       filepath.Join(dir, id+".raft"),
+		Secret:    "secret",
+		Bootstrap: join == "",
+		Join:      join,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func eventually(t *testing.T, what string, ok func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if ok() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestCluster(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	dir := t.TempDir()
+
+	n1 := startNode(t, ctx, dir, "n1", "")
+	if _, err := n1.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s0", Key: "app/0", Value: "MA=="}); err != nil {
+		t.Fatal(err)
+	}
+	n2 := startNode(t, ctx, dir, "n2", n1.srv.URL)
+	// joining through a node that is not the leader is forwarded by the server, here it's the leader
+	n3 := startNode(t, ctx, dir, "n3", n1.srv.URL)
+	nodes := []*testNode{n1, n2, n3}
+	defer func() {
+		for _, n := range nodes {
+			n.Close()
+		}
+	}()
+	for _, n := range nodes {
+		if _, err := n.GetSecret(ctx, "app/0"); err != nil {
+			t.Fatalf("expected the joined node to have the log, got %v", err)
+		}
+	}
+
+	// a follower forwards its writes to the leader
+	if _, err := n2.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s1", Key: "app/1", Value: "MQ=="}); err != nil {
+		t.Fatal(err)
+	}
+	err := n1.WithTx(ctx, func(q sqlc.Querier) error {
+		secret, err := q.GetSecret(ctx, "app/1")
+		if err != nil {
+			return err
+		}
+		_, err = q.UpdateSecret(ctx, sqlc.UpdateSecretParams{Key: secret.Key, Value: "dXBkYXRlZA=="})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n1.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s2", Key: "app/1", Value: "MQ=="}); err == nil {
+		t.Error("expected a duplicate key to fail")
+	}
+	for _, n := range nodes {
+		eventually(t, "the nodes to apply the writes", func() bool {
+			secrets, err := n.ListSecrets(ctx)
+			secret, _ := n.GetSecret(ctx, "app/1")
+			return err == nil && len(secrets) == 2 && secret.Value == "dXBkYXRlZA=="
+		})
+	}
+
+	status, err := n3.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Leader != "n1" || len(status.Nodes) != 3 || status.Nodes[0].Url != n1.srv.URL {
+		t.Errorf("expected n1 to lead 3 nodes, got %+v", status)
+	}
+
+	// the others elect a new leader and keep taking writes
+	n1.Close()
+	nodes = nodes[1:]
+	eventually(t, "a new leader", func() bool {
+		return n2.Leader() || n3.Leader()
+	})
+	for i, n := range nodes {
+		eventually(t, "the write to succeed", func() bool {
+			_, err := n.CreateSecret(ctx, sqlc.CreateSecretParams{ID: fmt.Sprintf("after-%d", i), Key: fmt.Sprintf("after/%d", i), Value: "eA=="})
+			return err == nil
+		})
+	}
+	for _, n := range nodes {
+		eventually(t, "the nodes to apply the writes", func() bool {
+			secrets, err := n.ListSecrets(ctx)
+			return err == nil && len(secrets) == 4
+		})
+	}
+}
+
+func TestClusterCreatedAt(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	dir := t.TempDir()
+
+	n1 := startNode(t, ctx, dir, "n1", "")
+	defer n1.Close()
+	created, err := n1.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s0", Key: "app/0", Value: "MA=="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := n1.CreateUser(ctx, sqlc.CreateUserParams{ID: "u0", Username: "alice", Password: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CURRENT_TIMESTAMP has a resolution of a second, the late node applies the log in a later one
+	time.Sleep(1100 * time.Millisecond)
+	n2 := startNode(t, ctx, dir, "n2", n1.srv.URL)
+	defer n2.Close()
+
+	for _, n := range []*testNode{n1, n2} {
+		var secret sqlc.Secret
+		eventually(t, "the node to apply the log", func() bool {
+			secret, err = n.GetSecret(ctx, "app/0")
+			return err == nil
+		})
+		if secret.CreatedAt == nil || created.CreatedAt == nil || !secret.CreatedAt.Equal(*created.CreatedAt) {
+			t.Errorf("expected the secret created at %v, got %v", created.CreatedAt, secret.CreatedAt)
+		}
+		stored, err := n.GetUser(ctx, "u0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.CreatedAt == nil || user.CreatedAt == nil || !stored.CreatedAt.Equal(*user.CreatedAt) {
+			t.Errorf("expected the user created at %v, got %v", user.CreatedAt, stored.CreatedAt)
+		}
+	}
+}
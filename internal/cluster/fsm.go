@@ -0,0 +1,158 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/hashicorp/raft"
+	"github.com/tomek7667/secrets/internal/backup"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+// command is a log entry, the statements of a transaction
+type command struct {
+	Statements []sqlite.Statement `json:"statements"`
+}
+
+// fsm applies the log to the sqlite db. The db is kept on disk, so that a restarted node only applies
+// the entries after its applied index instead of restoring the last snapshot and the log after it.
+type fsm struct {
+	db      *sqlite.Client
+	dir     string
+	applied atomic.Uint64
+	// onChange is set by Node.OnChange
+	onChange atomic.Pointer[func()]
+}
+
+func newFsm(ctx context.Context, db *sqlite.Client, dir string) (*fsm, error) {
+	// cluster_state and cluster_node are created by the migrations, see schema/14_cluster.sql
+	applied, err := appliedIndex(ctx, db.DB)
+	if err != nil {
+		return nil, err
+	}
+	f := &fsm{db: db, dir: dir}
+	f.applied.Store(applied)
+	return f, nil
+}
+
+func appliedIndex(ctx context.Context, db *sql.DB) (uint64, error) {
+	var applied uint64
+	if err := db.QueryRowContext(ctx, "SELECT applied_index FROM cluster_state").Scan(&applied); err != nil {
+		return 0, fmt.Errorf("failed to read the applied index: %w", err)
+	}
+	return applied, nil
+}
+
+// Apply runs the statements of the entry with the applied index in one transaction. It returns
+// the error of the statements, which fail the same way on every node, only the index moves on.
+func (f *fsm) Apply(entry *raft.Log) interface{} {
+	if entry.Index <= f.applied.Load() {
+		return nil
+	}
+	defer f.changed()
+	ctx := context.Background()
+	mark, err := sqlite.NewStatement("UPDATE cluster_state SET applied_index = ?", int64(entry.Index))
+	if err != nil {
+		return err
+	}
+	var cmd command
+	if err := json.Unmarshal(entry.Data, &cmd); err != nil {
+		err = fmt.Errorf("invalid log entry %d: %w", entry.Index, err)
+		return errors.Join(err, f.mark(ctx, entry.Index, mark))
+	}
+	if err := f.db.ExecStatements(ctx, append(cmd.Statements, mark)); err != nil {
+		return errors.Join(err, f.mark(ctx, entry.Index, mark))
+	}
+	f.applied.Store(entry.Index)
+	return nil
+}
+
+func (f *fsm) mark(ctx context.Context, index uint64, mark sqlite.Statement) error {
+	if err := f.db.ExecStatements(ctx, []sqlite.Statement{mark}); err != nil {
+		slog.Error("failed to save the applied index", "index", index, "err", err)
+		return err
+	}
+	f.applied.Store(index)
+	return nil
+}
+
+// Snapshot copies the db, Apply waits for it, but Persist runs alongside the next entries
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	path := filepath.Join(f.dir, "snapshot-"+rand.Text()+".sqlite")
+	if err := backup.Snapshot(context.Background(), f.db.DB, path); err != nil {
+		return nil, err
+	}
+	return &snapshot{path: path}, nil
+}
+
+// Restore replaces the db with a snapshot, unless the db is already past it, e.g. when the node restarts
+func (f *fsm) Restore(r io.ReadCloser) error {
+	defer r.Close()
+	ctx := context.Background()
+	path := filepath.Join(f.dir, "restore-"+rand.Text()+".sqlite")
+	defer os.Remove(path)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	if err := errors.Join(err, file.Close()); err != nil {
+		return fmt.Errorf("failed to save the snapshot: %w", err)
+	}
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return err
+	}
+	applied, err := appliedIndex(ctx, db)
+	db.Close()
+	if err != nil {
+		return err
+	}
+	if applied <= f.applied.Load() {
+		return nil
+	}
+	if err := f.db.Replace(ctx, path); err != nil {
+		return fmt.Errorf("failed to restore the snapshot: %w", err)
+	}
+	f.applied.Store(applied)
+	f.changed()
+	slog.Info("restored a snapshot of the cluster", "index", applied)
+	return nil
+}
+
+func (f *fsm) changed() {
+	if fn := f.onChange.Load(); fn != nil {
+		(*fn)()
+	}
+}
+
+type snapshot struct {
+	path string
+}
+
+func (s *snapshot) Persist(sink raft.SnapshotSink) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		sink.Cancel()
+		return err
+	}
+	defer file.Close()
+	if _, err := io.Copy(sink, file); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *snapshot) Release() {
+	os.Remove(s.path)
+}
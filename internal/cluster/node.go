@@ -0,0 +1,483 @@
+// Package cluster replicates the sqlite db of several servers through raft, the leader takes the writes
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+	"github.com/pressly/goose/v3"
+	"github.com/tomek7667/secrets/internal/metrics"
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/sqlite"
+	"github.com/tomek7667/secrets/internal/tracing"
+)
+
+const (
+	// AuthScheme of the Authorization header nodes send each other, "Cluster <secret>"
+	AuthScheme = "Cluster"
+
+	applyTimeout    = 10 * time.Second
+	registerRetry   = time.Second
+	snapshotsRetain = 2
+)
+
+var (
+	ErrNoLeader  = errors.New("the cluster has no leader")
+	ErrNotLeader = errors.New("the node is not the leader")
+)
+
+type Config struct {
+	// ID names the node in the cluster, e.g. "node1"
+	ID string
+	// Address is the host:port raft listens on, which the other nodes reach it at, e.g. "10.0.0.1:7780"
+	Address string
+	// Url of the http api, which the other nodes forward writes to, e.g. "http://10.0.0.1:7770"
+	Url string
+	// Dir keeps the raft log and snapshots
+	Dir string
+	// Secret authenticates the nodes to each other's http api
+	Secret string
+	// Bootstrap starts a new cluster of the node
+	Bootstrap bool
+	// Join is the url of a node of the cluster the new node joins, see Node.Join
+	Join string
+}
+
+type NodeStatus struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	Url     string `json:"url"`
+	Voter   bool   `json:"voter"`
+	Leader  bool   `json:"leader"`
+}
+
+type Status struct {
+	ID            string       `json:"id"`
+	State         string       `json:"state"`
+	Leader        string       `json:"leader"`
+	Term          uint64       `json:"term"`
+	CommitIndex   uint64       `json:"commit_index"`
+	AppliedIndex  uint64       `json:"applied_index"`
+	LastContactAt *time.Time   `json:"last_contact_at"`
+	Nodes         []NodeStatus `json:"nodes"`
+}
+
+// Node is a storage.Store whose writes go through the raft log, reads are served by the local db
+type Node struct {
+	*sqlc.Queries
+
+	db        *sqlite.Client
+	config    Config
+	raft      *raft.Raft
+	fsm       *fsm
+	logs      *raftboltdb.BoltStore
+	transport *raft.NetworkTransport
+	client    *http.Client
+
+	// mu orders the writes, so that the leader records a transaction on the state the log applies it to
+	mu sync.Mutex
+	// barrierTerm is the term the leader has applied the entries of the previous terms in
+	barrierTerm uint64
+	stop        chan struct{}
+}
+
+// Open starts the raft node of the db. A new node bootstraps the cluster or joins it and waits until
+// it has caught up, so that the server doesn't see an empty db. A restarted node continues where it stopped.
+func Open(ctx context.Context, db *sqlite.Client, config Config) (*Node, error) {
+	if config.ID == "" || config.Address == "" || config.Url == "" || config.Secret == "" {
+		return nil, fmt.Errorf("a cluster node needs an id, an address, an url and a secret")
+	}
+	if err := os.MkdirAll(config.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := newFsm(ctx, db, config.Dir)
+	if err != nil {
+		return nil, err
+	}
+	logger := hclog.New(&hclog.LoggerOptions{Name: "raft", Level: hclog.Warn})
+	logs, err := raftboltdb.New(raftboltdb.Options{Path: filepath.Join(config.Dir, "raft.db")})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the raft log: %w", err)
+	}
+	snapshots, err := raft.NewFileSnapshotStoreWithLogger(config.Dir, snapshotsRetain, logger)
+	if err != nil {
+		logs.Close()
+		return nil, err
+	}
+	advertise, err := net.ResolveTCPAddr("tcp", config.Address)
+	if err != nil {
+		logs.Close()
+		return nil, fmt.Errorf("invalid cluster address '%s': %w", config.Address, err)
+	}
+	transport, err := raft.NewTCPTransportWithLogger(config.Address, advertise, 3, applyTimeout, logger)
+	if err != nil {
+		logs.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", config.Address, err)
+	}
+	hasState, err := raft.HasExistingState(logs, logs, snapshots)
+	if err != nil {
+		transport.Close()
+		logs.Close()
+		return nil, err
+	}
+	if !hasState && !config.Bootstrap && config.Join == "" {
+		transport.Close()
+		logs.Close()
+		return nil, fmt.Errorf("a new node needs to bootstrap a cluster or to join one")
+	}
+	raftConfig := raft.DefaultConfig()
+	raftConfig.LocalID = raft.ServerID(config.ID)
+	raftConfig.Logger = logger
+	r, err := raft.NewRaft(raftConfig, f, logs, logs, snapshots, transport)
+	if err != nil {
+		transport.Close()
+		logs.Close()
+		return nil, err
+	}
+	n := &Node{
+		Queries:   sqlc.New(tracing.DB(metrics.DB(db.ReadDB), "sqlite")),
+		db:        db,
+		config:    config,
+		raft:      r,
+		fsm:       f,
+		logs:      logs,
+		transport: transport,
+		client:    &http.Client{Timeout: applyTimeout},
+		stop:      make(chan struct{}),
+	}
+	if !hasState {
+		if err := n.start(ctx); err != nil {
+			n.Close()
+			return nil, err
+		}
+	}
+	go n.register()
+	return n, nil
+}
+
+// start bootstraps or joins the cluster and waits until the node knows the leader and has its log
+func (n *Node) start(ctx context.Context) error {
+	var index uint64
+	if n.config.Bootstrap {
+		configuration := raft.Configuration{Servers: []raft.Server{{ID: raft.ServerID(n.config.ID), Address: raft.ServerAddress(n.config.Address)}}}
+		if err := n.raft.BootstrapCluster(configuration).Error(); err != nil {
+			return fmt.Errorf("failed to bootstrap the cluster: %w", err)
+		}
+	} else {
+		var err error
+		index, err = n.join(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to join %s: %w", n.config.Join, err)
+		}
+	}
+	slog.Info("waiting for the cluster", "id", n.config.ID)
+	for {
+		if n.raft.AppliedIndex() >= index {
+			if n.Leader() || n.leaderUrl(ctx) != "" {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// join asks the cluster to add the node, it returns the index of the configuration with the node
+func (n *Node) join(ctx context.Context) (uint64, error) {
+	body, _ := json.Marshal(map[string]string{"id": n.config.ID, "address": n.config.Address})
+	var joined struct {
+		Index uint64 `json:"index"`
+	}
+	if err := n.request(ctx, strings.TrimSuffix(n.config.Join, "/")+"/api/cluster/join", body, &joined); err != nil {
+		return 0, err
+	}
+	return joined.Index, nil
+}
+
+// register saves the url of the node, once there is a leader to take the write
+func (n *Node) register() {
+	for {
+		statement, _ := sqlite.NewStatement(
+			"INSERT INTO cluster_node (id, address, url) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET address = excluded.address, url = excluded.url",
+			n.config.ID, n.config.Address, n.config.Url,
+		)
+		err := n.replicate(context.Background(), nil, statement)
+		if err == nil {
+			return
+		}
+		slog.Debug("failed to register the cluster node, retrying", "id", n.config.ID, "err", err)
+		select {
+		case <-n.stop:
+			return
+		case <-time.After(registerRetry):
+		}
+	}
+}
+
+// WithTx runs fn in a transaction, which is rolled back, and replicates the writes fn made to all the nodes.
+// The leader applies them through the log, the other nodes forward them to the leader. Reads of fn see the
+// local db, so that handlers reading before they write run on the leader, the server forwards them there.
+func (n *Node) WithTx(ctx context.Context, fn func(q sqlc.Querier) error) error {
+	return n.replicate(ctx, fn)
+}
+
+// replicate is WithTx followed by the extra statements, fn can be nil
+func (n *Node) replicate(ctx context.Context, fn func(q sqlc.Querier) error, extra ...sqlite.Statement) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	leader := n.Leader()
+	if leader {
+		if err := n.barrier(); err != nil {
+			return err
+		}
+	}
+	var statements []sqlite.Statement
+	if fn != nil {
+		var err error
+		if statements, err = n.db.Record(ctx, fn); err != nil {
+			return err
+		}
+	}
+	statements = append(statements, extra...)
+	if len(statements) == 0 {
+		return nil
+	}
+	if leader {
+		return n.apply(statements)
+	}
+	return n.forward(ctx, statements)
+}
+
+// Apply replicates the statements a node forwarded to the leader
+func (n *Node) Apply(ctx context.Context, statements []sqlite.Statement) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.Leader() {
+		return ErrNotLeader
+	}
+	if err := n.barrier(); err != nil {
+		return err
+	}
+	return n.apply(statements)
+}
+
+// barrier waits until a new leader has applied the log of the previous leaders
+func (n *Node) barrier() error {
+	term := n.raft.CurrentTerm()
+	if n.barrierTerm == term {
+		return nil
+	}
+	if err := n.raft.Barrier(applyTimeout).Error(); err != nil {
+		return fmt.Errorf("failed to apply the log: %w", err)
+	}
+	n.barrierTerm = term
+	return nil
+}
+
+func (n *Node) apply(statements []sqlite.Statement) error {
+	data, err := json.Marshal(command{Statements: statements})
+	if err != nil {
+		return err
+	}
+	future := n.raft.Apply(data, applyTimeout)
+	if err := future.Error(); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) || errors.Is(err, raft.ErrLeadershipLost) {
+			return ErrNotLeader
+		}
+		return fmt.Errorf("failed to replicate the write: %w", err)
+	}
+	if err, ok := future.Response().(error); ok {
+		return err
+	}
+	return nil
+}
+
+func (n *Node) forward(ctx context.Context, statements []sqlite.Statement) error {
+	url := n.leaderUrl(ctx)
+	if url == "" {
+		return ErrNoLeader
+	}
+	body, err := json.Marshal(command{Statements: statements})
+	if err != nil {
+		return err
+	}
+	return n.request(ctx, url+"/api/cluster/apply", body, nil)
+}
+
+// request posts to the api of another node, data is set to the data of the response
+func (n *Node) request(ctx context.Context, url string, body []byte, data any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", AuthScheme+" "+n.config.Secret)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(resBody, &response); err != nil {
+		return fmt.Errorf("%s responded with %s", url, res.Status)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s: %s", url, res.Status, response.Message)
+	}
+	if data == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Data, data)
+}
+
+// Authorized checks the "Cluster <secret>" authorization header of the requests of the other nodes
+func (n *Node) Authorized(r *http.Request) bool {
+	secret, found := strings.CutPrefix(strings.TrimSpace(r.Header.Get("Authorization")), AuthScheme+" ")
+	return found && subtle.ConstantTimeCompare([]byte(secret), []byte(n.config.Secret)) == 1
+}
+
+// Join adds a node to the cluster as a voter, only the leader can
+func (n *Node) Join(id, address string) (uint64, error) {
+	if !n.Leader() {
+		return 0, ErrNotLeader
+	}
+	future := n.raft.AddVoter(raft.ServerID(id), raft.ServerAddress(address), 0, applyTimeout)
+	if err := future.Error(); err != nil {
+		return 0, fmt.Errorf("failed to add node %s: %w", id, err)
+	}
+	return future.Index(), nil
+}
+
+// Remove takes a node out of the cluster, e.g. one that is gone for good, only the leader can
+func (n *Node) Remove(ctx context.Context, id string) error {
+	if !n.Leader() {
+		return ErrNotLeader
+	}
+	if err := n.raft.RemoveServer(raft.ServerID(id), 0, applyTimeout).Error(); err != nil {
+		return fmt.Errorf("failed to remove node %s: %w", id, err)
+	}
+	statement, _ := sqlite.NewStatement("DELETE FROM cluster_node WHERE id = ?", id)
+	return n.replicate(ctx, nil, statement)
+}
+
+// Leader tells whether the node is the leader
+func (n *Node) Leader() bool {
+	return n.raft.State() == raft.Leader
+}
+
+// LeaderUrl is the api url of the leader, empty while there is no leader or the node doesn't know its url yet
+func (n *Node) LeaderUrl() string {
+	return n.leaderUrl(context.Background())
+}
+
+func (n *Node) leaderUrl(ctx context.Context) string {
+	_, id := n.raft.LeaderWithID()
+	if id == "" {
+		return ""
+	}
+	var url string
+	if err := n.db.ReadDB.QueryRowContext(ctx, "SELECT url FROM cluster_node WHERE id = ?", string(id)).Scan(&url); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
+func (n *Node) Status(ctx context.Context) (Status, error) {
+	_, leader := n.raft.LeaderWithID()
+	status := Status{
+		ID:           n.config.ID,
+		State:        strings.ToLower(n.raft.State().String()),
+		Leader:       string(leader),
+		Term:         n.raft.CurrentTerm(),
+		CommitIndex:  n.raft.CommitIndex(),
+		AppliedIndex: n.raft.AppliedIndex(),
+		Nodes:        []NodeStatus{},
+	}
+	if lastContact := n.raft.LastContact(); !lastContact.IsZero() {
+		status.LastContactAt = &lastContact
+	}
+	future := n.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return status, err
+	}
+	urls := map[string]string{}
+	rows, err := n.db.ReadDB.QueryContext(ctx, "SELECT id, url FROM cluster_node")
+	if err != nil {
+		return status, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, url string
+		if err := rows.Scan(&id, &url); err != nil {
+			return status, err
+		}
+		urls[id] = url
+	}
+	for _, server := range future.Configuration().Servers {
+		status.Nodes = append(status.Nodes, NodeStatus{
+			ID:      string(server.ID),
+			Address: string(server.Address),
+			Url:     urls[string(server.ID)],
+			Voter:   server.Suffrage == raft.Voter,
+			Leader:  server.ID == leader,
+		})
+	}
+	return status, rows.Err()
+}
+
+// OnChange calls fn after the node applies an entry of the log or restores a snapshot,
+// i.e. whenever the local db changes, whichever node took the write
+func (n *Node) OnChange(fn func()) {
+	n.fsm.onChange.Store(&fn)
+}
+
+// Sqlite is the local db, e.g. for backups, writing to it directly would make the node diverge
+func (n *Node) Sqlite() *sqlite.Client {
+	return n.db
+}
+
+// Migrate migrates the local db, every node runs the same version
+func (n *Node) Migrate(ctx context.Context) ([]*goose.MigrationResult, error) {
+	return n.db.Migrate(ctx)
+}
+
+func (n *Node) MigrateDown(ctx context.Context) (*goose.MigrationResult, error) {
+	return n.db.MigrateDown(ctx)
+}
+
+func (n *Node) MigrationStatus(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return n.db.MigrationStatus(ctx)
+}
+
+// Close leaves the raft of the cluster running on the other nodes, which elect a new leader if needed
+func (n *Node) Close() error {
+	close(n.stop)
+	err := n.raft.Shutdown().Error()
+	return errors.Join(err, n.transport.Close(), n.logs.Close(), n.db.Close())
+}
@@ -0,0 +1,262 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+// The writes of sqlc.Querier go through the raft log, the reads are the embedded queries of the reader pool.
+// A write missing here fails, since the readers are query only.
+
+// write replicates the statements fn makes and returns its result, see Node.WithTx
+func write[T any](ctx context.Context, n *Node, fn func(q sqlc.Querier) (T, error)) (T, error) {
+	var result T
+	err := n.WithTx(ctx, func(q sqlc.Querier) error {
+		var err error
+		result, err = fn(q)
+		return err
+	})
+	return result, err
+}
+
+func (n *Node) CreateDynamicRole(ctx context.Context, arg sqlc.CreateDynamicRoleParams) (sqlc.DynamicRole, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.DynamicRole, error) {
+		return q.CreateDynamicRole(ctx, arg)
+	})
+}
+
+func (n *Node) CreateLease(ctx context.Context, arg sqlc.CreateLeaseParams) (sqlc.Lease, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Lease, error) {
+		return q.CreateLease(ctx, arg)
+	})
+}
+
+func (n *Node) CreateLog(ctx context.Context, arg sqlc.CreateLogParams) (sqlc.Log, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Log, error) {
+		return q.CreateLog(ctx, arg)
+	})
+}
+
+func (n *Node) CreatePermission(ctx context.Context, arg sqlc.CreatePermissionParams) (sqlc.Permission, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Permission, error) {
+		return q.CreatePermission(ctx, arg)
+	})
+}
+
+func (n *Node) CreateRotationPolicy(ctx context.Context, arg sqlc.CreateRotationPolicyParams) (sqlc.RotationPolicy, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.RotationPolicy, error) {
+		return q.CreateRotationPolicy(ctx, arg)
+	})
+}
+
+func (n *Node) CreateSecret(ctx context.Context, arg sqlc.CreateSecretParams) (sqlc.Secret, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Secret, error) {
+		return q.CreateSecret(ctx, arg)
+	})
+}
+
+func (n *Node) CreateSecretVersion(ctx context.Context, arg sqlc.CreateSecretVersionParams) (sqlc.SecretVersion, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.SecretVersion, error) {
+		return q.CreateSecretVersion(ctx, arg)
+	})
+}
+
+func (n *Node) CreateShare(ctx context.Context, arg sqlc.CreateShareParams) (sqlc.Share, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Share, error) {
+		return q.CreateShare(ctx, arg)
+	})
+}
+
+func (n *Node) CreateToken(ctx context.Context, arg sqlc.CreateTokenParams) (sqlc.Token, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Token, error) {
+		return q.CreateToken(ctx, arg)
+	})
+}
+
+func (n *Node) CreateUser(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.User, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.User, error) {
+		return q.CreateUser(ctx, arg)
+	})
+}
+
+func (n *Node) CreateWebhook(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.Webhook, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Webhook, error) {
+		return q.CreateWebhook(ctx, arg)
+	})
+}
+
+func (n *Node) CreateWebhookDelivery(ctx context.Context, arg sqlc.CreateWebhookDeliveryParams) (sqlc.WebhookDelivery, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.WebhookDelivery, error) {
+		return q.CreateWebhookDelivery(ctx, arg)
+	})
+}
+
+func (n *Node) DeleteDynamicRole(ctx context.Context, id string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteDynamicRole(ctx, id)
+	})
+}
+
+func (n *Node) DeleteExpiredShares(ctx context.Context, expiresAt time.Time) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteExpiredShares(ctx, expiresAt)
+	})
+}
+
+func (n *Node) DeleteLogs(ctx context.Context) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteLogs(ctx)
+	})
+}
+
+func (n *Node) DeletePermission(ctx context.Context, id string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeletePermission(ctx, id)
+	})
+}
+
+func (n *Node) DeleteRotationPolicy(ctx context.Context, id string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteRotationPolicy(ctx, id)
+	})
+}
+
+func (n *Node) DeleteRotationPolicyBySecretKey(ctx context.Context, secretKey string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteRotationPolicyBySecretKey(ctx, secretKey)
+	})
+}
+
+func (n *Node) DeleteSecret(ctx context.Context, key string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteSecret(ctx, key)
+	})
+}
+
+func (n *Node) DeleteSecretVersions(ctx context.Context, secretKey string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteSecretVersions(ctx, secretKey)
+	})
+}
+
+func (n *Node) DeleteShare(ctx context.Context, id string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteShare(ctx, id)
+	})
+}
+
+func (n *Node) DeleteToken(ctx context.Context, id string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteToken(ctx, id)
+	})
+}
+
+func (n *Node) DeleteUser(ctx context.Context, id string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteUser(ctx, id)
+	})
+}
+
+func (n *Node) DeleteWebhook(ctx context.Context, id string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteWebhook(ctx, id)
+	})
+}
+
+func (n *Node) DeleteWebhookDeliveries(ctx context.Context, webhookID string) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.DeleteWebhookDeliveries(ctx, webhookID)
+	})
+}
+
+func (n *Node) MarkLeasesStale(ctx context.Context, arg sqlc.MarkLeasesStaleParams) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.MarkLeasesStale(ctx, arg)
+	})
+}
+
+func (n *Node) RenewLease(ctx context.Context, arg sqlc.RenewLeaseParams) (sqlc.Lease, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Lease, error) {
+		return q.RenewLease(ctx, arg)
+	})
+}
+
+func (n *Node) RevokeLease(ctx context.Context, arg sqlc.RevokeLeaseParams) (sqlc.Lease, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Lease, error) {
+		return q.RevokeLease(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateDynamicRole(ctx context.Context, arg sqlc.UpdateDynamicRoleParams) (sqlc.DynamicRole, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.DynamicRole, error) {
+		return q.UpdateDynamicRole(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateLeaseError(ctx context.Context, arg sqlc.UpdateLeaseErrorParams) error {
+	return n.WithTx(ctx, func(q sqlc.Querier) error {
+		return q.UpdateLeaseError(ctx, arg)
+	})
+}
+
+func (n *Node) UpdatePermission(ctx context.Context, arg sqlc.UpdatePermissionParams) (sqlc.Permission, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Permission, error) {
+		return q.UpdatePermission(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateRotationPolicy(ctx context.Context, arg sqlc.UpdateRotationPolicyParams) (sqlc.RotationPolicy, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.RotationPolicy, error) {
+		return q.UpdateRotationPolicy(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateRotationPolicyResult(ctx context.Context, arg sqlc.UpdateRotationPolicyResultParams) (sqlc.RotationPolicy, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.RotationPolicy, error) {
+		return q.UpdateRotationPolicyResult(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateSecret(ctx context.Context, arg sqlc.UpdateSecretParams) (sqlc.Secret, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Secret, error) {
+		return q.UpdateSecret(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateSecretDates(ctx context.Context, arg sqlc.UpdateSecretDatesParams) (sqlc.Secret, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Secret, error) {
+		return q.UpdateSecretDates(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateToken(ctx context.Context, arg sqlc.UpdateTokenParams) (sqlc.Token, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Token, error) {
+		return q.UpdateToken(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateUser(ctx context.Context, arg sqlc.UpdateUserParams) (sqlc.User, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.User, error) {
+		return q.UpdateUser(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateWebhook(ctx context.Context, arg sqlc.UpdateWebhookParams) (sqlc.Webhook, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Webhook, error) {
+		return q.UpdateWebhook(ctx, arg)
+	})
+}
+
+func (n *Node) UpdateWebhookDeliveryAttempt(ctx context.Context, arg sqlc.UpdateWebhookDeliveryAttemptParams) (sqlc.WebhookDelivery, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.WebhookDelivery, error) {
+		return q.UpdateWebhookDeliveryAttempt(ctx, arg)
+	})
+}
+
+func (n *Node) ViewShare(ctx context.Context, arg sqlc.ViewShareParams) (sqlc.Share, error) {
+	return write(ctx, n, func(q sqlc.Querier) (sqlc.Share, error) {
+		return q.ViewShare(ctx, arg)
+	})
+}
@@ -0,0 +1,79 @@
+package dynamic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/generator"
+)
+
+// Engine issues short-lived credentials on a target system and revokes them again
+type Engine interface {
+	Create(ctx context.Context, creds Credentials, expiresAt time.Time) error
+	Revoke(ctx context.Context, username string) error
+}
+
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type Kind string
+
+const (
+	SqlKind Kind = "sql"
+)
+
+// passwordCharset leaves out quotes, so that passwords can be templated into sql statements
+const passwordCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// New returns the engine of a kind configured with its json config
+func New(kind Kind, config string) (Engine, error) {
+	switch kind {
+	case SqlKind:
+		var e SqlEngine
+		if err := json.Unmarshal([]byte(config), &e); err != nil {
+			return nil, fmt.Errorf("invalid sql engine config: %w", err)
+		}
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+		return e, nil
+	default:
+		return nil, fmt.Errorf("unknown engine '%s'", kind)
+	}
+}
+
+var roleNameRe = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+func ValidateRoleName(name string) error {
+	if !roleNameRe.MatchString(name) {
+		return fmt.Errorf("role name must be 1 to 32 lowercase letters, digits or underscores")
+	}
+	return nil
+}
+
+// NewCredentials returns a unique username for the role, e.g. "v_readonly_1a2b3c4d5e6f" with a random password
+func NewCredentials(roleName string) (Credentials, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return Credentials{}, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	password, err := generator.Generate(generator.Options{
+		Type:    generator.PasswordType,
+		Length:  32,
+		Charset: passwordCharset,
+	})
+	if err != nil {
+		return Credentials{}, err
+	}
+	return Credentials{
+		Username: fmt.Sprintf("v_%s_%s", roleName, hex.EncodeToString(b)),
+		Password: password,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package dynamic_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/dynamic"
+)
+
+func TestSqlEngine(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "target.sqlite")
+	target, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	_, err = target.Exec("CREATE TABLE account (name TEXT PRIMARY KEY, password TEXT NOT NULL, valid_until TEXT NOT NULL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine, err := dynamic.New(dynamic.SqlKind, utils.MustMarshal(dynamic.SqlEngine{
+		Driver:               dynamic.SqliteDriver,
+		ConnectionUrl:        path,
+		CreationStatements:   []string{"INSERT INTO account VALUES ('{{name}}', '{{password}}', '{{expiration}}')"},
+		RevocationStatements: []string{"DELETE FROM account WHERE name = '{{name}}'"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := dynamic.NewCredentials("readonly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := engine.Create(ctx, creds, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	var password, validUntil string
+	err = target.QueryRow("SELECT password, valid_until FROM account WHERE name = ?", creds.Username).Scan(&password, &validUntil)
+	if err != nil {
+		t.Fatalf("the user was not created: %s", err)
+	}
+	if password != creds.Password {
+		t.Errorf("expected password '%s', got '%s'", creds.Password, password)
+	}
+	if validUntil != "2030-01-02 03:04:05+00" {
+		t.Errorf("unexpected expiration '%s'", validUntil)
+	}
+
+	if err := engine.Revoke(ctx, creds.Username); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := target.QueryRow("SELECT COUNT(*) FROM account").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected the user to be revoked, %d left", count)
+	}
+}
+
+func TestSqlEngineRollsBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "target.sqlite")
+	engine := dynamic.SqlEngine{
+		Driver:        dynamic.SqliteDriver,
+		ConnectionUrl: path,
+		CreationStatements: []string{
+			"CREATE TABLE account (name TEXT)",
+			"INSERT INTO missing VALUES ('{{name}}')",
+		},
+		RevocationStatements: []string{"DELETE FROM account WHERE name = '{{name}}'"},
+	}
+	if err := engine.Create(context.Background(), dynamic.Credentials{Username: "u"}, time.Now()); err == nil {
+		t.Fatal("expected the second statement to fail")
+	}
+	target, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	var count int
+	if err := target.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'account'").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Error("expected the created table to be rolled back")
+	}
+}
+
+func TestNewCredentials(t *testing.T) {
+	a, err := dynamic.NewCredentials("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := dynamic.NewCredentials("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Username == b.Username || a.Password == b.Password {
+		t.Error("expected unique credentials")
+	}
+	if err := dynamic.ValidateRoleName("bad-name"); err == nil {
+		t.Error("expected an invalid role name")
+	}
+}
@@ -0,0 +1,90 @@
+package dynamic
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	SqliteDriver   = "sqlite"
+	PostgresDriver = "postgres"
+)
+
+var sqlDriverNames = map[string]string{
+	SqliteDriver:   "sqlite3",
+	PostgresDriver: "pgx",
+}
+
+// SqlEngine runs templated statements against a database. The statements can use
+// {{name}}, {{password}} and {{expiration}} (e.g. "2026-01-01 00:00:00+00"), e.g. for postgres:
+//
+//	CREATE ROLE "{{name}}" WITH LOGIN PASSWORD '{{password}}' VALID UNTIL '{{expiration}}'
+//	DROP ROLE IF EXISTS "{{name}}"
+type SqlEngine struct {
+	Driver               string   `json:"driver"`
+	ConnectionUrl        string   `json:"connection_url"`
+	CreationStatements   []string `json:"creation_statements"`
+	RevocationStatements []string `json:"revocation_statements"`
+}
+
+func (e SqlEngine) Validate() error {
+	if _, ok := sqlDriverNames[e.Driver]; !ok {
+		return fmt.Errorf("unknown driver '%s', expected %s or %s", e.Driver, SqliteDriver, PostgresDriver)
+	}
+	if e.ConnectionUrl == "" {
+		return fmt.Errorf("connection_url is required")
+	}
+	if len(e.CreationStatements) == 0 {
+		return fmt.Errorf("at least one creation statement is required")
+	}
+	if len(e.RevocationStatements) == 0 {
+		return fmt.Errorf("at least one revocation statement is required")
+	}
+	return nil
+}
+
+func (e SqlEngine) Create(ctx context.Context, creds Credentials, expiresAt time.Time) error {
+	replacer := strings.NewReplacer(
+		"{{name}}", creds.Username,
+		"{{password}}", creds.Password,
+		"{{expiration}}", expiresAt.UTC().Format("2006-01-02 15:04:05-07"),
+	)
+	return e.exec(ctx, e.CreationStatements, replacer)
+}
+
+func (e SqlEngine) Revoke(ctx context.Context, username string) error {
+	replacer := strings.NewReplacer("{{name}}", username)
+	return e.exec(ctx, e.RevocationStatements, replacer)
+}
+
+// exec runs the statements in a single transaction, so that a failed lease leaves nothing behind
+func (e SqlEngine) exec(ctx context.Context, statements []string, replacer *strings.Replacer) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	db, err := sql.Open(sqlDriverNames[e.Driver], e.ConnectionUrl)
+	if err != nil {
+		return fmt.Errorf("failed to open %s database: %w", e.Driver, err)
+	}
+	defer db.Close()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin a transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for i, statement := range statements {
+		if _, err := tx.ExecContext(ctx, replacer.Replace(statement)); err != nil {
+			return fmt.Errorf("statement %d failed: %w", i+1, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
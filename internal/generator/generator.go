@@ -0,0 +1,157 @@
+package generator
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type Type string
+
+const (
+	PasswordType Type = "password"
+	BytesType    Type = "bytes"
+	RsaType      Type = "rsa"
+	Ed25519Type  Type = "ed25519"
+	UuidType     Type = "uuid"
+	// HexType is a shorthand for hex encoded random bytes
+	HexType Type = "hex"
+)
+
+const (
+	DefaultCharset        = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()-_=+[]{}<>?"
+	defaultPasswordLength = 32
+	defaultBytesLength    = 32
+	defaultRsaBits        = 4096
+	maxLength             = 4096
+)
+
+type Options struct {
+	Type Type `json:"type"`
+	// Length is the number of characters of a password or the number of random bytes
+	Length int `json:"length,omitempty"`
+	// Charset lists the characters a password is drawn from
+	Charset string `json:"charset,omitempty"`
+	// Encoding of random bytes: hex (default) or base64
+	Encoding string `json:"encoding,omitempty"`
+	// Bits is the rsa key size
+	Bits int `json:"bits,omitempty"`
+}
+
+// Generate returns a new random value. Keypairs are returned as the PKCS #8
+// private key followed by the PKIX public key, both PEM encoded.
+func Generate(opts Options) (string, error) {
+	switch opts.Type {
+	case PasswordType:
+		return password(opts.Length, opts.Charset)
+	case BytesType:
+		return randomBytes(opts.Length, opts.Encoding)
+	case HexType:
+		return randomBytes(opts.Length, "hex")
+	case UuidType:
+		return uuid()
+	case RsaType:
+		return rsaKeypair(opts.Bits)
+	case Ed25519Type:
+		return ed25519Keypair()
+	default:
+		return "", fmt.Errorf("unknown generator type '%s'", opts.Type)
+	}
+}
+
+func password(length int, charset string) (string, error) {
+	if length == 0 {
+		length = defaultPasswordLength
+	}
+	if length < 0 || length > maxLength {
+		return "", fmt.Errorf("password length must be between 1 and %d", maxLength)
+	}
+	if charset == "" {
+		charset = DefaultCharset
+	}
+	chars := []rune(charset)
+	max := big.NewInt(int64(len(chars)))
+	var sb strings.Builder
+	for range length {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to read random number: %w", err)
+		}
+		sb.WriteRune(chars[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+func randomBytes(length int, encoding string) (string, error) {
+	if length == 0 {
+		length = defaultBytesLength
+	}
+	if length < 0 || length > maxLength {
+		return "", fmt.Errorf("bytes length must be between 1 and %d", maxLength)
+	}
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	switch encoding {
+	case "", "hex":
+		return hex.EncodeToString(b), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(b), nil
+	default:
+		return "", fmt.Errorf("unknown encoding '%s', expected hex or base64", encoding)
+	}
+}
+
+// uuid returns a random (version 4) uuid
+func uuid() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+func rsaKeypair(bits int) (string, error) {
+	if bits == 0 {
+		bits = defaultRsaBits
+	}
+	if bits != 2048 && bits != 3072 && bits != 4096 {
+		return "", fmt.Errorf("rsa key size must be 2048, 3072 or 4096 bits")
+	}
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate rsa key: %w", err)
+	}
+	return encodeKeypair(key, &key.PublicKey)
+}
+
+func ed25519Keypair() (string, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate ed25519 key: %w", err)
+	}
+	return encodeKeypair(priv, pub)
+}
+
+func encodeKeypair(priv, pub any) (string, error) {
+	privDer, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	pubDer, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDer})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer})), nil
+}
@@ -0,0 +1,128 @@
+package generator_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/generator"
+)
+
+func TestPassword(t *testing.T) {
+	value, err := generator.Generate(generator.Options{Type: generator.PasswordType, Length: 64, Charset: "ab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(value) != 64 {
+		t.Errorf("expected 64 characters, got %d", len(value))
+	}
+	if strings.Trim(value, "ab") != "" {
+		t.Errorf("'%s' contains characters outside of the charset", value)
+	}
+
+	value, err = generator.Generate(generator.Options{Type: generator.PasswordType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(value) != 32 {
+		t.Errorf("expected the default length of 32, got %d", len(value))
+	}
+}
+
+func TestBytes(t *testing.T) {
+	value, err := generator.Generate(generator.Options{Type: generator.BytesType, Length: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := hex.DecodeString(value); err != nil || len(b) != 16 {
+		t.Errorf("'%s' is not 16 hex encoded bytes", value)
+	}
+
+	value, err = generator.Generate(generator.Options{Type: generator.BytesType, Length: 16, Encoding: "base64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := base64.StdEncoding.DecodeString(value); err != nil || len(b) != 16 {
+		t.Errorf("'%s' is not 16 base64 encoded bytes", value)
+	}
+
+	if _, err := generator.Generate(generator.Options{Type: generator.BytesType, Encoding: "base32"}); err == nil {
+		t.Error("expected an error for an unknown encoding")
+	}
+}
+
+func TestHex(t *testing.T) {
+	value, err := generator.Generate(generator.Options{Type: generator.HexType, Length: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := hex.DecodeString(value); err != nil || len(b) != 8 {
+		t.Errorf("'%s' is not 8 hex encoded bytes", value)
+	}
+}
+
+func TestUuid(t *testing.T) {
+	uuidRe := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	value, err := generator.Generate(generator.Options{Type: generator.UuidType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !uuidRe.MatchString(value) {
+		t.Errorf("'%s' is not a version 4 uuid", value)
+	}
+}
+
+func TestKeypairs(t *testing.T) {
+	scenarios := map[string]generator.Options{
+		"rsa":     {Type: generator.RsaType, Bits: 2048},
+		"ed25519": {Type: generator.Ed25519Type},
+	}
+	for name, opts := range scenarios {
+		t.Run(name, func(tt *testing.T) {
+			value, err := generator.Generate(opts)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			privBlock, rest := pem.Decode([]byte(value))
+			if privBlock == nil || privBlock.Type != "PRIVATE KEY" {
+				tt.Fatalf("missing private key block in %s", value)
+			}
+			pubBlock, _ := pem.Decode(rest)
+			if pubBlock == nil || pubBlock.Type != "PUBLIC KEY" {
+				tt.Fatalf("missing public key block in %s", value)
+			}
+			priv, err := x509.ParsePKCS8PrivateKey(privBlock.Bytes)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			pub, err := x509.ParsePKIXPublicKey(pubBlock.Bytes)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			switch priv := priv.(type) {
+			case *rsa.PrivateKey:
+				if !priv.PublicKey.Equal(pub) {
+					tt.Error("public key doesn't belong to the private key")
+				}
+			case ed25519.PrivateKey:
+				if !priv.Public().(ed25519.PublicKey).Equal(pub) {
+					tt.Error("public key doesn't belong to the private key")
+				}
+			default:
+				tt.Errorf("unexpected private key type %T", priv)
+			}
+		})
+	}
+}
+
+func TestUnknownType(t *testing.T) {
+	if _, err := generator.Generate(generator.Options{Type: "dice"}); err == nil {
+		t.Error("expected an error for an unknown generator type")
+	}
+}
@@ -0,0 +1,110 @@
+// Package metrics holds the prometheus metrics of the process, which the server exposes on /metrics
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+const namespace = "secrets"
+
+// Registry has the metrics of the process, the ones of a server's db are registered by the server
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HttpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "code"})
+	HttpRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to respond to HTTP requests by route pattern and method, the whole stream for /api/secrets/watch.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+	Logins = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "User logins by result, success or failure.",
+	}, []string{"result"})
+	TokenReads = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_reads_total",
+		Help:      "Secret reads of api tokens (get, list and export) by token id.",
+	}, []string{"token"})
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by a rate limiter, login or share.",
+	}, []string{"limiter"})
+	AuditQueue = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "audit_queue_depth",
+		Help:      "Audit log entries waiting to be written to the db and the sinks.",
+	})
+	DbQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time to run the db queries by sqlc query name, until the first row for the ones returning rows.",
+		Buckets:   []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"query"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// DB times the queries run through db, see DbQueryDuration
+func DB(db sqlc.DBTX) sqlc.DBTX {
+	return timedDB{db}
+}
+
+type timedDB struct {
+	db sqlc.DBTX
+}
+
+func (t timedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observe(query, time.Now())
+	return t.db.ExecContext(ctx, query, args...)
+}
+
+func (t timedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.db.PrepareContext(ctx, query)
+}
+
+func (t timedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer observe(query, time.Now())
+	return t.db.QueryContext(ctx, query, args...)
+}
+
+func (t timedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observe(query, time.Now())
+	return t.db.QueryRowContext(ctx, query, args...)
+}
+
+func observe(query string, start time.Time) {
+	DbQueryDuration.WithLabelValues(QueryName(query)).Observe(time.Since(start).Seconds())
+}
+
+// QueryName is the name sqlc puts in the first line of its queries, "-- name: GetSecret :one", or "other"
+func QueryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "other"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
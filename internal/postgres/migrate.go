@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+	"github.com/tomek7667/secrets/schema"
+)
+
+func (c *Client) migrationProvider() (*goose.Provider, error) {
+	migrations, err := fs.Sub(schema.PostgresMigrations, "postgres")
+	if err != nil {
+		return nil, err
+	}
+	// an advisory lock keeps concurrent starts from migrating at once
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, c.DB, migrations, goose.WithSessionLocker(locker))
+}
+
+// Migrate applies the pending migrations. It refuses a database migrated by a newer version,
+// which the queries of this version can't be trusted with.
+func (c *Client) Migrate(ctx context.Context) ([]*goose.MigrationResult, error) {
+	p, err := c.migrationProvider()
+	if err != nil {
+		return nil, err
+	}
+	current, latest, err := p.GetVersions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the schema version: %w", err)
+	}
+	if current > latest {
+		return nil, fmt.Errorf("database schema version %d is newer than the latest known version %d, upgrade secretsserver", current, latest)
+	}
+	results, err := p.Up(ctx)
+	if err != nil {
+		return results, fmt.Errorf("failed to migrate: %w", err)
+	}
+	return results, nil
+}
+
+// MigrateDown rolls back the last applied migration
+func (c *Client) MigrateDown(ctx context.Context) (*goose.MigrationResult, error) {
+	p, err := c.migrationProvider()
+	if err != nil {
+		return nil, err
+	}
+	result, err := p.Down(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to roll back: %w", err)
+	}
+	return result, nil
+}
+
+func (c *Client) MigrationStatus(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	p, err := c.migrationProvider()
+	if err != nil {
+		return nil, err
+	}
+	return p.Status(ctx)
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+// rebindDB passes the queries to db with postgres placeholders
+type rebindDB struct {
+	db sqlc.DBTX
+}
+
+func (r rebindDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.db.ExecContext(ctx, rebind(query), args...)
+}
+
+func (r rebindDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.db.PrepareContext(ctx, rebind(query))
+}
+
+func (r rebindDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.db.QueryContext(ctx, rebind(query), args...)
+}
+
+func (r rebindDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.db.QueryRowContext(ctx, rebind(query), args...)
+}
+
+// rebound caches rebind, the queries are the constants of the sqlc package
+var rebound sync.Map
+
+// rebind turns the sqlite placeholders, ? and the numbered ?NNN sqlc uses for a repeated sqlc.arg,
+// into $N. Quoted strings, identifiers and comments are left as they are.
+func rebind(query string) string {
+	if cached, ok := rebound.Load(query); ok {
+		return cached.(string)
+	}
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				b.WriteString(query[i:])
+				i = len(query)
+				continue
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				b.WriteString(query[i:])
+				i = len(query)
+				continue
+			}
+			b.WriteString(query[i : i+end+1])
+			i += end
+		case c == '?':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			b.WriteByte('$')
+			if j > i+1 {
+				b.WriteString(query[i+1 : j])
+			} else {
+				n++
+				b.WriteString(strconv.Itoa(n))
+			}
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	rebound.Store(query, b.String())
+	return b.String()
+}
@@ -0,0 +1,401 @@
+package replication
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// AuthScheme prefixes the replication secret in the Authorization header of followers, e.g. "Replica <secret>"
+	AuthScheme = "Replica"
+	// HeaderGeneration and HeaderSeq carry the position of a snapshot
+	HeaderGeneration = "X-Replication-Generation"
+	HeaderSeq        = "X-Replication-Seq"
+	// PollWait is how long the primary holds the request of a follower that is up to date
+	PollWait = 25 * time.Second
+
+	retryInterval = 2 * time.Second
+	// the header of the database file, https://www.sqlite.org/fileformat2.html#the_database_header
+	dbHeaderSize = 100
+)
+
+type FollowerStatus struct {
+	Role            string   `json:"role"`
+	Primary         string   `json:"primary"`
+	Position        Position `json:"position"`
+	PrimaryPosition Position `json:"primary_position"`
+	Behind          uint64   `json:"behind"`
+	// LagSeconds is how much older the last applied transaction is than the newest one of the primary
+	LagSeconds    float64    `json:"lag_seconds"`
+	LastContactAt *time.Time `json:"last_contact_at"`
+	LastError     *string    `json:"last_error"`
+}
+
+// Follower keeps a replica of the primary's database up to date by writing the pages of its transactions
+// into the file, holding an exclusive lock so that readers don't see half of a transaction.
+// The replica is in the rollback journal mode, in which readers notice the file change counter bumped
+// after every write, see sqlite.OpenReplica.
+type Follower struct {
+	Primary string
+	secret  string
+	id      string
+	path    string
+	client  *http.Client
+
+	// file is never closed before the replica, closing any descriptor of a file drops the posix locks
+	// sqlite holds on it in this process
+	file     *os.File
+	lock     *sql.DB
+	pageSize int64
+	// counter is the file change counter written after every apply, increasing even when the
+	// primary's page 1 goes back to a change counter a reader has seen
+	counter uint32
+
+	mu                 sync.Mutex
+	position           Position
+	appliedAt          *time.Time
+	primaryPosition    Position
+	primaryCommittedAt *time.Time
+	lastContactAt      *time.Time
+	lastError          *string
+	onChange           func()
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewFollower downloads a snapshot of the primary into path, replacing the database there
+func NewFollower(ctx context.Context, primary, secret, id, path string) (*Follower, error) {
+	if _, err := url.Parse(primary); err != nil || !strings.HasPrefix(primary, "http") {
+		return nil, fmt.Errorf("invalid primary url '%s', expected e.g. http://10.0.0.1:7770", primary)
+	}
+	f := &Follower{
+		Primary: strings.TrimSuffix(primary, "/"),
+		secret:  secret,
+		id:      id,
+		path:    path,
+		client:  &http.Client{Timeout: PollWait + 30*time.Second},
+	}
+	snapshot, position, err := f.fetchSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(path + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove the old %s: %w", path+suffix, err)
+		}
+	}
+	if err := os.WriteFile(path, snapshot, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write the snapshot: %w", err)
+	}
+	f.file, err = os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the replica: %w", err)
+	}
+	f.pageSize = pageSize(snapshot)
+	f.counter = binary.BigEndian.Uint32(snapshot[24:])
+	if err := f.touchHeader(int64(len(snapshot)) / f.pageSize); err != nil {
+		f.file.Close()
+		return nil, err
+	}
+	f.lock, err = sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=%d", path, (30*time.Second).Milliseconds()))
+	if err != nil {
+		f.file.Close()
+		return nil, fmt.Errorf("failed to open the replica: %w", err)
+	}
+	f.lock.SetMaxOpenConns(1)
+	f.position = position
+	f.primaryPosition = position
+	slog.Info("downloaded a snapshot of the primary", "primary", f.Primary, "generation", position.Generation, "seq", position.Seq, "bytes", len(snapshot))
+	return f, nil
+}
+
+// Start applies the transactions of the primary in the background until Stop
+func (f *Follower) Start(ctx context.Context) {
+	ctx, f.cancel = context.WithCancel(ctx)
+	f.done = make(chan struct{})
+	go f.run(ctx)
+}
+
+func (f *Follower) run(ctx context.Context) {
+	defer close(f.done)
+	for ctx.Err() == nil {
+		err := f.follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		now := time.Now().UTC()
+		f.mu.Lock()
+		if err != nil {
+			errMsg := err.Error()
+			f.lastError = &errMsg
+		} else {
+			f.lastError = nil
+			f.lastContactAt = &now
+		}
+		f.mu.Unlock()
+		if err != nil {
+			slog.Error("failed to replicate the primary", "err", err, "primary", f.Primary)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+		}
+	}
+}
+
+// OnChange calls fn after the follower writes transactions of the primary or a new snapshot into the replica
+func (f *Follower) OnChange(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onChange = fn
+}
+
+func (f *Follower) changed() {
+	f.mu.Lock()
+	fn := f.onChange
+	f.mu.Unlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+// Stop stops following the primary, waiting for the transaction being applied
+func (f *Follower) Stop() {
+	if f.cancel == nil {
+		return
+	}
+	f.cancel()
+	<-f.done
+}
+
+// Close closes the replica file, after the database using it was closed
+func (f *Follower) Close() error {
+	return errors.Join(f.lock.Close(), f.file.Close())
+}
+
+func (f *Follower) Status() FollowerStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := FollowerStatus{
+		Role:            "follower",
+		Primary:         f.Primary,
+		Position:        f.position,
+		PrimaryPosition: f.primaryPosition,
+		LastContactAt:   f.lastContactAt,
+		LastError:       f.lastError,
+	}
+	if f.position.Generation == f.primaryPosition.Generation && f.position.Seq < f.primaryPosition.Seq {
+		status.Behind = f.primaryPosition.Seq - f.position.Seq
+		if f.appliedAt != nil && f.primaryCommittedAt != nil {
+			status.LagSeconds = f.primaryCommittedAt.Sub(*f.appliedAt).Seconds()
+		}
+	}
+	return status
+}
+
+// follow polls the primary once and applies what it returns
+func (f *Follower) follow(ctx context.Context) error {
+	f.mu.Lock()
+	position := f.position
+	f.mu.Unlock()
+	changes, err := f.fetchChanges(ctx, position)
+	if errors.Is(err, ErrSnapshotNeeded) {
+		return f.resync(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.primaryPosition = changes.Position
+	f.primaryCommittedAt = changes.CommittedAt
+	f.mu.Unlock()
+	if len(changes.Batches) == 0 {
+		return nil
+	}
+	if err := f.apply(ctx, changes.Batches); err != nil {
+		return err
+	}
+	last := changes.Batches[len(changes.Batches)-1]
+	f.mu.Lock()
+	f.position = Position{Generation: position.Generation, Seq: last.Seq}
+	f.appliedAt = &last.CommittedAt
+	f.mu.Unlock()
+	f.changed()
+	return nil
+}
+
+// apply writes the pages of the transactions, holding the exclusive lock of the replica
+func (f *Follower) apply(ctx context.Context, batches []Batch) error {
+	return f.locked(ctx, func() error {
+		for _, batch := range batches {
+			for _, page := range batch.Pages {
+				if int64(len(page.Data)) != f.pageSize {
+					return fmt.Errorf("page %d has %d bytes, the replica has pages of %d", page.No, len(page.Data), f.pageSize)
+				}
+				if _, err := f.file.WriteAt(page.Data, int64(page.No-1)*f.pageSize); err != nil {
+					return fmt.Errorf("failed to write page %d: %w", page.No, err)
+				}
+			}
+		}
+		dbSize := int64(batches[len(batches)-1].DbSize)
+		if err := f.file.Truncate(dbSize * f.pageSize); err != nil {
+			return fmt.Errorf("failed to truncate the replica: %w", err)
+		}
+		return f.touchHeader(dbSize)
+	})
+}
+
+// resync replaces the replica with a new snapshot of the primary
+func (f *Follower) resync(ctx context.Context) error {
+	snapshot, position, err := f.fetchSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	err = f.locked(ctx, func() error {
+		if _, err := f.file.WriteAt(snapshot, 0); err != nil {
+			return fmt.Errorf("failed to write the snapshot: %w", err)
+		}
+		if err := f.file.Truncate(int64(len(snapshot))); err != nil {
+			return fmt.Errorf("failed to truncate the replica: %w", err)
+		}
+		f.pageSize = pageSize(snapshot)
+		return f.touchHeader(int64(len(snapshot)) / f.pageSize)
+	})
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.position = position
+	f.primaryPosition = position
+	f.appliedAt = nil
+	f.mu.Unlock()
+	f.changed()
+	slog.Info("downloaded a snapshot of the primary", "primary", f.Primary, "generation", position.Generation, "seq", position.Seq, "bytes", len(snapshot))
+	return nil
+}
+
+// locked runs fn holding the exclusive lock of the replica, which readers wait for with their busy timeout
+func (f *Follower) locked(ctx context.Context, fn func() error) error {
+	conn, err := f.lock.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open the replica: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "BEGIN EXCLUSIVE"); err != nil {
+		return fmt.Errorf("failed to lock the replica: %w", err)
+	}
+	// nothing was written through sqlite, so the rollback only releases the lock
+	defer conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK")
+	if err := fn(); err != nil {
+		return err
+	}
+	if err := f.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync the replica: %w", err)
+	}
+	return nil
+}
+
+// touchHeader keeps the replica in the rollback journal mode and bumps its change counter,
+// so that readers drop the pages they have cached
+func (f *Follower) touchHeader(dbSize int64) error {
+	header := make([]byte, dbHeaderSize)
+	if _, err := f.file.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("failed to read the replica header: %w", err)
+	}
+	// file format write and read versions, 1 is the rollback journal and 2 is wal
+	header[18], header[19] = 1, 1
+	f.counter++
+	binary.BigEndian.PutUint32(header[24:], f.counter)
+	binary.BigEndian.PutUint32(header[28:], uint32(dbSize))
+	// the size in the header is valid when the version-valid-for number matches the change counter
+	binary.BigEndian.PutUint32(header[92:], f.counter)
+	if _, err := f.file.WriteAt(header, 0); err != nil {
+		return fmt.Errorf("failed to write the replica header: %w", err)
+	}
+	return nil
+}
+
+func pageSize(db []byte) int64 {
+	size := int64(binary.BigEndian.Uint16(db[16:]))
+	if size == 1 {
+		return 65536
+	}
+	return size
+}
+
+func (f *Follower) request(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.Primary+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", AuthScheme+" "+f.secret)
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the primary: %w", err)
+	}
+	if res.StatusCode == http.StatusGone {
+		res.Body.Close()
+		return nil, ErrSnapshotNeeded
+	}
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("the primary responded to %s with %s: %s", path, res.Status, body)
+	}
+	return res, nil
+}
+
+func (f *Follower) fetchSnapshot(ctx context.Context) ([]byte, Position, error) {
+	res, err := f.request(ctx, "/api/replication/snapshot", url.Values{"follower": {f.id}})
+	if err != nil {
+		return nil, Position{}, err
+	}
+	defer res.Body.Close()
+	snapshot, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, Position{}, fmt.Errorf("failed to download the snapshot: %w", err)
+	}
+	if len(snapshot) < dbHeaderSize || !strings.HasPrefix(string(snapshot), "SQLite format 3\x00") {
+		return nil, Position{}, fmt.Errorf("the snapshot is not a sqlite database")
+	}
+	seq, err := strconv.ParseUint(res.Header.Get(HeaderSeq), 10, 64)
+	if err != nil {
+		return nil, Position{}, fmt.Errorf("invalid %s of the snapshot: %w", HeaderSeq, err)
+	}
+	return snapshot, Position{Generation: res.Header.Get(HeaderGeneration), Seq: seq}, nil
+}
+
+func (f *Follower) fetchChanges(ctx context.Context, from Position) (Changes, error) {
+	res, err := f.request(ctx, "/api/replication/changes", url.Values{
+		"follower":   {f.id},
+		"generation": {from.Generation},
+		"seq":        {strconv.FormatUint(from.Seq, 10)},
+	})
+	if err != nil {
+		return Changes{}, err
+	}
+	defer res.Body.Close()
+	var body struct {
+		Data Changes `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return Changes{}, fmt.Errorf("failed to decode the changes: %w", err)
+	}
+	return body.Data, nil
+}
@@ -0,0 +1,304 @@
+package replication
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+const (
+	// pollInterval is how often the primary reads the wal for new transactions
+	pollInterval = 100 * time.Millisecond
+	// checkpointSize is the size of the wal the primary checkpoints it at, sqlite's own checkpoints are off
+	checkpointSize = 4 << 20
+	// logSize limits the transactions kept for followers, the ones further behind download a snapshot
+	logSize = 16 << 20
+	// followerTimeout is how long a follower that stopped polling is still listed
+	followerTimeout = 10 * time.Minute
+)
+
+// ErrSnapshotNeeded is returned to followers whose position the primary can't continue from
+var ErrSnapshotNeeded = errors.New("the position is not in the replication log, download a snapshot")
+
+// Batch is a transaction committed on the primary
+type Batch struct {
+	Seq         uint64    `json:"seq"`
+	CommittedAt time.Time `json:"committed_at"`
+	// DbSize is the size of the database in pages after the transaction
+	DbSize uint32 `json:"db_size"`
+	Pages  []Page `json:"pages"`
+}
+
+// Position is where a follower is in the replication log. A generation starts with a snapshot
+// and changes whenever the primary can't guarantee its log has every transaction, e.g. after a restart.
+type Position struct {
+	Generation string `json:"generation"`
+	Seq        uint64 `json:"seq"`
+}
+
+// Changes are the transactions after the position of a follower, up to the position of the primary
+type Changes struct {
+	Position    Position   `json:"position"`
+	CommittedAt *time.Time `json:"committed_at"`
+	Batches     []Batch    `json:"batches"`
+}
+
+type FollowerInfo struct {
+	ID         string    `json:"id"`
+	Seq        uint64    `json:"seq"`
+	Behind     uint64    `json:"behind"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type PrimaryStatus struct {
+	Role        string         `json:"role"`
+	Position    Position       `json:"position"`
+	CommittedAt *time.Time     `json:"committed_at"`
+	Followers   []FollowerInfo `json:"followers"`
+}
+
+// Primary ships the transactions of a sqlite database to followers. It reads them from the wal,
+// the way litestream does, and checkpoints the wal itself once it has read it.
+type Primary struct {
+	db   *sql.DB
+	path string
+
+	mu          sync.Mutex
+	wal         walReader
+	position    Position
+	committedAt *time.Time
+	log         []Batch
+	logBytes    int
+	// changed is closed and replaced when a transaction is added to the log
+	changed   chan struct{}
+	followers map[string]FollowerInfo
+}
+
+// NewPrimary starts a generation of the database, whose writer must have wal_autocheckpoint=0,
+// as a checkpoint the primary hasn't read the wal before would lose transactions
+func NewPrimary(ctx context.Context, c *sqlite.Client) (*Primary, error) {
+	var autocheckpoint int
+	if err := c.DB.QueryRowContext(ctx, "PRAGMA wal_autocheckpoint").Scan(&autocheckpoint); err != nil {
+		return nil, fmt.Errorf("failed to check wal_autocheckpoint: %w", err)
+	}
+	if autocheckpoint != 0 {
+		return nil, fmt.Errorf("replication needs the sqlite pragma wal_autocheckpoint=0, got %d", autocheckpoint)
+	}
+	p := &Primary{
+		db:        c.DB,
+		path:      c.Path,
+		wal:       walReader{path: c.Path + "-wal"},
+		changed:   make(chan struct{}),
+		followers: map[string]FollowerInfo{},
+	}
+	if err := p.newGeneration(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Run reads new transactions until ctx is done
+func (p *Primary) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := p.poll(); errors.Is(err, errWalRestarted) {
+			slog.Warn("the wal was checkpointed outside of replication, followers will download a snapshot")
+			if err := p.newGeneration(ctx); err != nil {
+				slog.Error("failed to start a replication generation", "err", err)
+			}
+			continue
+		} else if err != nil {
+			slog.Error("failed to read the wal", "err", err)
+			continue
+		}
+		if info, err := os.Stat(p.wal.path); err == nil && info.Size() > checkpointSize {
+			if err := p.checkpoint(ctx); err != nil {
+				slog.Error("failed to checkpoint the wal", "err", err)
+			}
+		}
+	}
+}
+
+// poll adds the transactions committed since the last poll to the log
+func (p *Primary) poll() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	commits, err := p.wal.read()
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	for _, c := range commits {
+		p.position.Seq++
+		p.log = append(p.log, Batch{Seq: p.position.Seq, CommittedAt: now, DbSize: c.dbSize, Pages: c.pages})
+		p.logBytes += len(c.pages) * int(p.wal.pageSize)
+	}
+	p.committedAt = &now
+	for p.logBytes > logSize && len(p.log) > 1 {
+		p.logBytes -= len(p.log[0].Pages) * int(p.wal.pageSize)
+		p.log = p.log[1:]
+	}
+	close(p.changed)
+	p.changed = make(chan struct{})
+	return nil
+}
+
+// lockWriter takes the single writer connection of the database, so that nothing is written until it's released
+func (p *Primary) lockWriter(ctx context.Context) (*sql.Conn, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the writer connection: %w", err)
+	}
+	return conn, nil
+}
+
+// checkpoint moves the wal into the database once the primary has read all of it
+func (p *Primary) checkpoint(ctx context.Context) error {
+	conn, err := p.lockWriter(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := p.poll(); err != nil {
+		return err
+	}
+	return p.checkpointRead(ctx, conn)
+}
+
+// checkpointRead checkpoints the wal, which the caller has read holding the writer
+func (p *Primary) checkpointRead(ctx context.Context, conn *sql.Conn) error {
+	var busy, walFrames, checkpointed int
+	if err := conn.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &walFrames, &checkpointed); err != nil {
+		return fmt.Errorf("failed to checkpoint: %w", err)
+	}
+	if walFrames == checkpointed {
+		// sqlite restarts the wal at the next write, which is fine as nothing in it is unread
+		p.mu.Lock()
+		p.wal.drained = true
+		p.mu.Unlock()
+	}
+	if busy != 0 {
+		return fmt.Errorf("the checkpoint was blocked by readers, %d of %d frames were checkpointed", checkpointed, walFrames)
+	}
+	return nil
+}
+
+// newGeneration empties the log and checkpoints the wal, so that every follower downloads a snapshot
+func (p *Primary) newGeneration(ctx context.Context) error {
+	conn, err := p.lockWriter(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	p.mu.Lock()
+	// the transactions in the wal are in the snapshots of the new generation
+	p.wal.drained = true
+	p.wal.restart()
+	p.position = Position{Generation: rand.Text()[:10], Seq: 0}
+	p.log = nil
+	p.logBytes = 0
+	close(p.changed)
+	p.changed = make(chan struct{})
+	p.mu.Unlock()
+	return p.checkpointRead(ctx, conn)
+}
+
+// Snapshot copies the database file and returns its position, which followers continue from with Since
+func (p *Primary) Snapshot(ctx context.Context) ([]byte, Position, error) {
+	conn, err := p.lockWriter(ctx)
+	if err != nil {
+		return nil, Position{}, err
+	}
+	defer conn.Close()
+	if err := p.poll(); err != nil {
+		return nil, Position{}, err
+	}
+	if err := p.checkpointRead(ctx, conn); err != nil {
+		return nil, Position{}, err
+	}
+	// nothing is written while the writer is held
+	snapshot, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, Position{}, fmt.Errorf("failed to copy the database: %w", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return snapshot, p.position, nil
+}
+
+// Since waits up to wait for transactions after the position of the follower
+func (p *Primary) Since(ctx context.Context, follower string, from Position, wait time.Duration) (Changes, error) {
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	for {
+		p.mu.Lock()
+		changes := Changes{Position: p.position, CommittedAt: p.committedAt}
+		changed := p.changed
+		p.followers[follower] = FollowerInfo{ID: follower, Seq: from.Seq, LastSeenAt: time.Now().UTC()}
+		if from.Generation != p.position.Generation || from.Seq > p.position.Seq {
+			p.mu.Unlock()
+			return Changes{}, ErrSnapshotNeeded
+		}
+		if from.Seq < p.position.Seq {
+			i, found := slices.BinarySearchFunc(p.log, from.Seq+1, func(b Batch, seq uint64) int {
+				return cmp.Compare(b.Seq, seq)
+			})
+			changes.Batches = slices.Clone(p.log[i:])
+			p.mu.Unlock()
+			if !found {
+				return Changes{}, ErrSnapshotNeeded
+			}
+			return changes, nil
+		}
+		p.mu.Unlock()
+		select {
+		case <-changed:
+		case <-timeout.C:
+			return changes, nil
+		case <-ctx.Done():
+			return Changes{}, ctx.Err()
+		}
+	}
+}
+
+func (p *Primary) Status() PrimaryStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := PrimaryStatus{
+		Role:        "primary",
+		Position:    p.position,
+		CommittedAt: p.committedAt,
+		Followers:   []FollowerInfo{},
+	}
+	for id, follower := range p.followers {
+		if time.Since(follower.LastSeenAt) > followerTimeout {
+			delete(p.followers, id)
+			continue
+		}
+		follower.Behind = p.position.Seq - min(follower.Seq, p.position.Seq)
+		status.Followers = append(status.Followers, follower)
+	}
+	slices.SortFunc(status.Followers, func(a, b FollowerInfo) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return status
+}
@@ -0,0 +1,162 @@
+package replication_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/replication"
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+// serve mirrors the replication routes of the secrets server
+func serve(t *testing.T, primary *replication.Primary) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/replication/snapshot", func(w http.ResponseWriter, r *http.Request) {
+		snapshot, position, err := primary.Snapshot(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(replication.HeaderGeneration, position.Generation)
+		w.Header().Set(replication.HeaderSeq, strconv.FormatUint(position.Seq, 10))
+		w.Write(snapshot)
+	})
+	mux.HandleFunc("GET /api/replication/changes", func(w http.ResponseWriter, r *http.Request) {
+		seq, _ := strconv.ParseUint(r.URL.Query().Get("seq"), 10, 64)
+		from := replication.Position{Generation: r.URL.Query().Get("generation"), Seq: seq}
+		changes, err := primary.Since(r.Context(), r.URL.Query().Get("follower"), from, time.Second)
+		if errors.Is(err, replication.ErrSnapshotNeeded) {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": changes})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func eventually(t *testing.T, what string, ok func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if ok() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestReplication(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+
+	db, err := sqlite.New(ctx, filepath.Join(dir, "primary.sqlite"), sqlite.Options{Pragmas: []string{"wal_autocheckpoint=0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s0", Key: "app/0", Value: "MA=="}); err != nil {
+		t.Fatal(err)
+	}
+	primary, err := replication.NewPrimary(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go primary.Run(ctx)
+	srv := serve(t, primary)
+
+	follower, err := replication.NewFollower(ctx, srv.URL, "secret", "f1", filepath.Join(dir, "replica.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follower.Close()
+	replica, err := sqlite.OpenReplica(ctx, filepath.Join(dir, "replica.sqlite"), sqlite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replica.Close()
+	if secret, err := replica.GetSecret(ctx, "app/0"); err != nil || secret.Value != "MA==" {
+		t.Fatalf("expected the snapshot to have the secret, got %v (%v)", secret, err)
+	}
+	follower.Start(ctx)
+
+	// readers must never see a half applied transaction
+	var readers sync.WaitGroup
+	readErrs := make(chan error, 1)
+	stopReading := make(chan struct{})
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-stopReading:
+				return
+			default:
+			}
+			if _, err := replica.ListSecrets(ctx); err != nil {
+				select {
+				case readErrs <- err:
+				default:
+				}
+				return
+			}
+		}
+	}()
+
+	for i := 1; i <= 200; i++ {
+		if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: fmt.Sprintf("s%d", i), Key: fmt.Sprintf("app/%d", i), Value: "eA=="}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.UpdateSecret(ctx, sqlc.UpdateSecretParams{Key: "app/0", Value: "dXBkYXRlZA=="}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the follower to apply the writes", func() bool {
+		secrets, err := replica.ListSecrets(ctx)
+		secret, _ := replica.GetSecret(ctx, "app/0")
+		return err == nil && len(secrets) == 201 && secret.Value == "dXBkYXRlZA=="
+	})
+	close(stopReading)
+	readers.Wait()
+	select {
+	case err := <-readErrs:
+		t.Fatalf("a read of the replica failed: %v", err)
+	default:
+	}
+	if status := follower.Status(); status.Behind != 0 || status.Position != primary.Status().Position {
+		t.Errorf("expected the follower to be up to date, got %+v", status)
+	}
+	if followers := primary.Status().Followers; len(followers) != 1 || followers[0].ID != "f1" {
+		t.Errorf("expected the primary to list the follower, got %+v", followers)
+	}
+	if _, err := replica.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "x", Key: "x", Value: "eA=="}); err == nil {
+		t.Error("expected the replica to refuse writes")
+	}
+	var integrity string
+	if err := replica.ReadDB.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&integrity); err != nil || integrity != "ok" {
+		t.Errorf("expected the replica to be intact, got %s (%v)", integrity, err)
+	}
+
+	follower.Stop()
+	if err := replica.Promote(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replica.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "x", Key: "x", Value: "eA=="}); err != nil {
+		t.Errorf("expected the promoted replica to be writable, got %v", err)
+	}
+	var journalMode string
+	if err := replica.DB.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode); err != nil || journalMode != "wal" {
+		t.Errorf("expected the promoted replica in the wal mode, got %s (%v)", journalMode, err)
+	}
+}
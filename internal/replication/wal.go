@@ -0,0 +1,161 @@
+package replication
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// https://www.sqlite.org/fileformat2.html#the_write_ahead_log
+const (
+	walHeaderSize      = 32
+	walFrameHeaderSize = 24
+	walMagicLE         = 0x377f0682
+	walMagicBE         = 0x377f0683
+)
+
+// Page is a database page written by a transaction
+type Page struct {
+	No   uint32 `json:"no"`
+	Data []byte `json:"data"`
+}
+
+// commit is a transaction read from the wal
+type commit struct {
+	// dbSize is the size of the database in pages after the transaction
+	dbSize uint32
+	pages  []Page
+}
+
+// walReader follows the -wal file of a database from the last transaction it has read.
+// Frames are only taken up to the last commit frame with a valid checksum, so that a transaction
+// being written is read once it's complete.
+type walReader struct {
+	path string
+
+	// the header of the wal being read, zero before the first read
+	pageSize     uint32
+	order        binary.ByteOrder
+	salt1, salt2 uint32
+	// the checksum of the last commit frame and the offset after it
+	sum1, sum2 uint32
+	offset     int64
+	// drained is set when the whole wal was read and checkpointed by the reader,
+	// so that a restarted wal doesn't mean missed transactions
+	drained bool
+}
+
+// errWalRestarted is returned when the wal was restarted with transactions the reader hasn't seen,
+// e.g. when another process checkpointed it
+var errWalRestarted = errors.New("the wal was restarted before it was read")
+
+// read returns the transactions committed since the last read
+func (w *walReader) read() ([]commit, error) {
+	f, err := os.Open(w.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, w.restart()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the wal: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, walHeaderSize)
+	if _, err := f.ReadAt(header, 0); errors.Is(err, io.EOF) {
+		// truncated by a checkpoint, the next write starts a new wal
+		return nil, w.restart()
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the wal header: %w", err)
+	}
+	salt1, salt2 := binary.BigEndian.Uint32(header[16:]), binary.BigEndian.Uint32(header[20:])
+	if w.offset == 0 || salt1 != w.salt1 || salt2 != w.salt2 {
+		if err := w.restart(); err != nil {
+			return nil, err
+		}
+		if !w.start(header) {
+			// the header is being written
+			return nil, nil
+		}
+	}
+
+	var commits []commit
+	var pending []Page
+	sum1, sum2 := w.sum1, w.sum2
+	frame := make([]byte, walFrameHeaderSize+int(w.pageSize))
+	for offset := w.offset; ; offset += int64(len(frame)) {
+		if _, err := f.ReadAt(frame, offset); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read a wal frame: %w", err)
+		}
+		if binary.BigEndian.Uint32(frame[8:]) != w.salt1 || binary.BigEndian.Uint32(frame[12:]) != w.salt2 {
+			// a frame of the previous wal
+			break
+		}
+		sum1, sum2 = w.checksum(sum1, sum2, frame[:8])
+		sum1, sum2 = w.checksum(sum1, sum2, frame[walFrameHeaderSize:])
+		if sum1 != binary.BigEndian.Uint32(frame[16:]) || sum2 != binary.BigEndian.Uint32(frame[20:]) {
+			// being written
+			break
+		}
+		pending = append(pending, Page{
+			No:   binary.BigEndian.Uint32(frame[0:]),
+			Data: append([]byte(nil), frame[walFrameHeaderSize:]...),
+		})
+		if dbSize := binary.BigEndian.Uint32(frame[4:]); dbSize > 0 {
+			commits = append(commits, commit{dbSize: dbSize, pages: pending})
+			pending = nil
+			w.sum1, w.sum2 = sum1, sum2
+			w.offset = offset + int64(len(frame))
+		}
+	}
+	if len(commits) > 0 {
+		w.drained = false
+	}
+	return commits, nil
+}
+
+// restart forgets the wal, which is fine when nothing was read from it or everything was checkpointed
+func (w *walReader) restart() error {
+	if w.offset > 0 && !w.drained {
+		return errWalRestarted
+	}
+	w.offset = 0
+	return nil
+}
+
+// start reads the header of a new wal
+func (w *walReader) start(header []byte) bool {
+	switch binary.BigEndian.Uint32(header) {
+	case walMagicLE:
+		w.order = binary.LittleEndian
+	case walMagicBE:
+		w.order = binary.BigEndian
+	default:
+		return false
+	}
+	sum1, sum2 := w.checksum(0, 0, header[:24])
+	if sum1 != binary.BigEndian.Uint32(header[24:]) || sum2 != binary.BigEndian.Uint32(header[28:]) {
+		return false
+	}
+	w.pageSize = binary.BigEndian.Uint32(header[8:])
+	if w.pageSize == 1 {
+		w.pageSize = 65536
+	}
+	w.salt1, w.salt2 = binary.BigEndian.Uint32(header[16:]), binary.BigEndian.Uint32(header[20:])
+	w.sum1, w.sum2 = sum1, sum2
+	w.offset = walHeaderSize
+	w.drained = false
+	return true
+}
+
+// checksum continues the cumulative checksum of the wal over data
+func (w *walReader) checksum(s1, s2 uint32, data []byte) (uint32, uint32) {
+	for i := 0; i+8 <= len(data); i += 8 {
+		s1 += w.order.Uint32(data[i:]) + s2
+		s2 += w.order.Uint32(data[i+4:]) + s1
+	}
+	return s1, s2
+}
@@ -31,5 +31,13 @@ func (s *Server) AddFrontendRoutes() {
 			w.WriteHeader(http.StatusOK)
 			w.Write(htmlToRender)
 		})
+		// one-time share page, it reads the decryption key from the url fragment
+		r.Get("/s/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("Referrer-Policy", "no-referrer")
+			w.WriteHeader(http.StatusOK)
+			w.Write(htmlToRender)
+		})
 	})
 }
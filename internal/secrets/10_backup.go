@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tomek7667/go-http-helpers/chii"
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/secrets/internal/backup"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+var errBackupUnsupported = errors.New("backups snapshot sqlite databases only, back up postgres with its own tools, e.g. pg_dump")
+
+// backupDb is the sqlite database backups snapshot, VACUUM INTO is refused by the query only readers
+func (s *Server) backupDb() (*sql.DB, error) {
+	c, ok := s.sqliteDb()
+	if !ok {
+		return nil, errBackupUnsupported
+	}
+	return c.DB, nil
+}
+
+type BackupDto struct {
+	// Passphrase or Recipients (age public keys) encrypt the backup
+	Passphrase string   `json:"passphrase"`
+	Recipients []string `json:"recipients"`
+}
+
+func (s *Server) AddBackupRoutes() {
+	auth := s.Router.With(chii.WithAuth(s.auther))
+	// Downloads an encrypted snapshot of the whole database, see backup.Write
+	auth.Post("/api/backup", func(w http.ResponseWriter, r *http.Request) {
+		user := chii.GetUser[sqlc.User](r)
+		dto, err := h.GetDto[BackupDto](r)
+		if err != nil {
+			h.ResBadRequest(w, err)
+			return
+		}
+		recipients, err := backup.Recipients(dto.Passphrase, dto.Recipients)
+		if err != nil {
+			h.ResBadRequest(w, err)
+			return
+		}
+		db, err := s.backupDb()
+		if err != nil {
+			h.ResBadRequest(w, err)
+			return
+		}
+		filename := backup.FileName(time.Now())
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.Header().Set("Cache-Control", "no-store")
+		if err := backup.Write(r.Context(), db, w, recipients...); err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("user %s failed to download a backup: %s", user.ID, err.Error()), r)
+			// a failed snapshot has written nothing yet, so the error still goes out as json
+			w.Header().Del("Content-Disposition")
+			h.ResErr(w, err)
+			return
+		}
+		s.Log(BackupEvent, fmt.Sprintf("user %s downloaded backup %s", user.ID, filename), r)
+	})
+
+	// Public, for monitoring: 503 when scheduled backups are disabled, failing or overdue
+	s.Router.Get("/api/health/backup", func(w http.ResponseWriter, r *http.Request) {
+		status := s.backupStatus(time.Now().UTC())
+		if status.Healthy {
+			h.ResSuccess(w, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]any{"success": false, "message": "backups are not healthy", "data": status})
+	})
+}
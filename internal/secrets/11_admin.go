@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tomek7667/go-http-helpers/chii"
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+type ConsistencyReport struct {
+	Consistent bool `json:"consistent"`
+	// OrphanedPermissions belong to deleted tokens
+	OrphanedPermissions  []sqlc.Permission            `json:"orphaned_permissions"`
+	ForeignKeyViolations []sqlite.ForeignKeyViolation `json:"foreign_key_violations"`
+}
+
+func (s *Server) AddAdminRoutes() {
+	auth := s.Router.With(chii.WithAuth(s.auther))
+	// Reports rows that reference deleted rows, there should be none since foreign keys are enforced
+	auth.Get("/api/admin/consistency", func(w http.ResponseWriter, r *http.Request) {
+		user := chii.GetUser[sqlc.User](r)
+		orphanedPermissions, err := s.Db.ListOrphanedPermissions(r.Context())
+		if err != nil {
+			h.ResErr(w, err)
+			return
+		}
+		// postgres always enforces foreign keys, sqlite only since they are turned on
+		violations := []sqlite.ForeignKeyViolation{}
+		if c, ok := s.sqliteDb(); ok {
+			violations, err = c.ForeignKeyViolations(r.Context())
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to check consistency: %s", user.ID, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+		}
+		report := ConsistencyReport{
+			Consistent:           len(orphanedPermissions) == 0 && len(violations) == 0,
+			OrphanedPermissions:  orphanedPermissions,
+			ForeignKeyViolations: violations,
+		}
+		s.Log(ConsistencyCheckEvent, fmt.Sprintf("user %s checked consistency: %d orphaned permissions, %d foreign key violations", user.ID, len(orphanedPermissions), len(violations)), r)
+		h.ResSuccess(w, report)
+	})
+}
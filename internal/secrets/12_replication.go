@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/secrets/internal/replication"
+)
+
+// authorizeReplica checks the "Replica <secret>" authorization header of followers and promotion requests.
+// When it returns false, the response has already been written.
+func (s *Server) authorizeReplica(w http.ResponseWriter, r *http.Request) bool {
+	secret, found := strings.CutPrefix(strings.TrimSpace(r.Header.Get("Authorization")), replication.AuthScheme+" ")
+	if !found || subtle.ConstantTimeCompare([]byte(secret), []byte(s.replication.secret)) != 1 {
+		s.Log(UnauthorizedEvent, fmt.Sprintf("invalid replication secret provided to %s", r.URL.Path), r)
+		h.ResUnauthorized(w)
+		return false
+	}
+	return true
+}
+
+func (s *Server) AddReplicationRoutes() {
+	if s.replication == nil {
+		return
+	}
+
+	// Downloads the database, which a follower continues from with /api/replication/changes
+	s.Router.Get("/api/replication/snapshot", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorizeReplica(w, r) {
+			return
+		}
+		primary, _ := s.replication.current()
+		if primary == nil {
+			h.ResBadRequest(w, fmt.Errorf("the server is not a primary"))
+			return
+		}
+		snapshot, position, err := primary.Snapshot(r.Context())
+		if err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("follower %s failed to download a snapshot: %s", r.URL.Query().Get("follower"), err.Error()), r)
+			h.ResErr(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.sqlite3")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set(replication.HeaderGeneration, position.Generation)
+		w.Header().Set(replication.HeaderSeq, strconv.FormatUint(position.Seq, 10))
+		w.Write(snapshot)
+	})
+
+	// Long polls the transactions after the position of a follower, 410 when it needs a snapshot
+	s.Router.Get("/api/replication/changes", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorizeReplica(w, r) {
+			return
+		}
+		primary, _ := s.replication.current()
+		if primary == nil {
+			h.ResBadRequest(w, fmt.Errorf("the server is not a primary"))
+			return
+		}
+		query := r.URL.Query()
+		seq, err := strconv.ParseUint(query.Get("seq"), 10, 64)
+		if err != nil {
+			h.ResBadRequest(w, fmt.Errorf("invalid seq '%s'", query.Get("seq")))
+			return
+		}
+		from := replication.Position{Generation: query.Get("generation"), Seq: seq}
+		changes, err := primary.Since(r.Context(), query.Get("follower"), from, replication.PollWait)
+		if errors.Is(err, replication.ErrSnapshotNeeded) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusGone)
+			json.NewEncoder(w).Encode(map[string]any{"success": false, "message": err.Error()})
+			return
+		}
+		if err != nil {
+			h.ResErr(w, err)
+			return
+		}
+		h.ResSuccess(w, changes)
+	})
+
+	// Public, for monitoring: the position of the server and the lag of the followers, or of the follower
+	s.Router.Get("/api/replication/status", func(w http.ResponseWriter, r *http.Request) {
+		primary, follower := s.replication.current()
+		if follower != nil {
+			h.ResSuccess(w, follower.Status())
+			return
+		}
+		h.ResSuccess(w, primary.Status())
+	})
+
+	// Makes a follower the primary, see `secretsserver promote`
+	s.Router.Post("/api/replication/promote", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorizeReplica(w, r) {
+			return
+		}
+		if err := s.promote(r.Context()); err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("failed to promote the follower: %s", err.Error()), r)
+			h.ResErr(w, err)
+			return
+		}
+		s.Log(PromoteEvent, "the follower was promoted to a primary", r)
+		primary, _ := s.replication.current()
+		h.ResSuccess(w, primary.Status())
+	})
+}
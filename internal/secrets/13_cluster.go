@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+type JoinClusterDto struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+}
+
+type ClusterCommandDto struct {
+	Statements []sqlite.Statement `json:"statements"`
+}
+
+// authorizeNode checks the "Cluster <secret>" authorization header of the other nodes.
+// When it returns false, the response has already been written.
+func (s *Server) authorizeNode(w http.ResponseWriter, r *http.Request) bool {
+	if !s.cluster.Authorized(r) {
+		s.Log(UnauthorizedEvent, fmt.Sprintf("invalid cluster secret provided to %s", r.URL.Path), r)
+		h.ResUnauthorized(w)
+		return false
+	}
+	return true
+}
+
+// AddClusterRoutes are served by the leader, the other nodes forward them, except for the status
+func (s *Server) AddClusterRoutes() {
+	if s.cluster == nil {
+		return
+	}
+
+	// Adds a new node, see `secretsserver --cluster-join`
+	s.Router.Post("/api/cluster/join", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorizeNode(w, r) {
+			return
+		}
+		dto, err := h.GetDto[JoinClusterDto](r)
+		if err != nil {
+			h.ResBadRequest(w, err)
+			return
+		}
+		if dto.ID == "" || dto.Address == "" {
+			h.ResBadRequest(w, fmt.Errorf("id and address are required"))
+			return
+		}
+		index, err := s.cluster.Join(dto.ID, dto.Address)
+		if err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("node %s at %s failed to join the cluster: %s", dto.ID, dto.Address, err.Error()), r)
+			h.ResErr(w, err)
+			return
+		}
+		s.Log(ClusterEvent, fmt.Sprintf("node %s at %s joined the cluster", dto.ID, dto.Address), r)
+		h.ResSuccess(w, map[string]uint64{"index": index})
+	})
+
+	// Removes a node that is gone for good, a cluster of 3 needs 2 to take writes
+	s.Router.Delete("/api/cluster/nodes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorizeNode(w, r) {
+			return
+		}
+		id := chi.URLParam(r, "id")
+		if err := s.cluster.Remove(r.Context(), id); err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("failed to remove node %s from the cluster: %s", id, err.Error()), r)
+			h.ResErr(w, err)
+			return
+		}
+		s.Log(ClusterEvent, fmt.Sprintf("node %s was removed from the cluster", id), r)
+		h.ResSuccess(w, nil)
+	})
+
+	// Replicates the writes of another node, e.g. its audit log
+	s.Router.Post("/api/cluster/apply", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorizeNode(w, r) {
+			return
+		}
+		dto, err := h.GetDto[ClusterCommandDto](r)
+		if err != nil {
+			h.ResBadRequest(w, err)
+			return
+		}
+		if err := s.cluster.Apply(r.Context(), dto.Statements); err != nil {
+			h.ResErr(w, err)
+			return
+		}
+		h.ResSuccess(w, nil)
+	})
+
+	// Public, for monitoring: the raft state of the node and the nodes of the cluster
+	s.Router.Get("/api/cluster/status", func(w http.ResponseWriter, r *http.Request) {
+		status, err := s.cluster.Status(r.Context())
+		if err != nil {
+			h.ResErr(w, err)
+			return
+		}
+		h.ResSuccess(w, status)
+	})
+}
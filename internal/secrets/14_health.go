@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/secrets/internal/buildinfo"
+	"github.com/tomek7667/secrets/internal/cluster"
+)
+
+const readyTimeout = 5 * time.Second
+
+// ready is what /readyz checks, "ok" or what is wrong for every check
+func (s *Server) ready(ctx context.Context) (map[string]string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+	checks := map[string]string{}
+	ok := true
+	fail := func(check string, err error) {
+		checks[check] = err.Error()
+		ok = false
+	}
+
+	// the status of the migrations reads the db, so it tells whether the db is reachable too
+	statuses, err := s.Db.MigrationStatus(ctx)
+	if err != nil {
+		fail("db", err)
+		fail("migrations", fmt.Errorf("unknown, the db is unreachable"))
+	} else {
+		checks["db"] = "ok"
+		pending := 0
+		for _, status := range statuses {
+			if status.State == goose.StatePending {
+				pending++
+			}
+		}
+		if pending > 0 {
+			fail("migrations", fmt.Errorf("%d pending, run secretsserver migrate up", pending))
+		} else {
+			checks["migrations"] = "ok"
+		}
+	}
+
+	// a node without a leader can't take writes, nor forward them
+	if s.cluster != nil {
+		if s.cluster.Leader() || s.cluster.LeaderUrl() != "" {
+			checks["cluster"] = "ok"
+		} else {
+			fail("cluster", cluster.ErrNoLeader)
+		}
+	}
+	return checks, ok
+}
+
+// AddHealthRoutes are served by every node, followers and cluster nodes included, without authentication
+func (s *Server) AddHealthRoutes() {
+	// Answers the sdk, see secretssdk.Client.Ping
+	s.Router.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		h.ResSuccess(w, nil)
+	})
+
+	// The process is alive, e.g. for a liveness probe
+	s.Router.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		h.ResSuccess(w, map[string]string{"status": "ok"})
+	})
+
+	// The server can serve requests: the db is reachable, migrated and, in a cluster, there is a leader
+	s.Router.Get("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		checks, ok := s.ready(r.Context())
+		if ok {
+			h.ResSuccess(w, checks)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]any{"success": false, "message": "not ready", "data": checks})
+	})
+
+	// The version the server was built from, the sdk warns when it isn't its own
+	s.Router.Get("/version", func(w http.ResponseWriter, r *http.Request) {
+		h.ResSuccess(w, buildinfo.Read())
+	})
+}
@@ -1,36 +1,116 @@
 package secrets
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/tomek7667/go-http-helpers/chii"
 	"github.com/tomek7667/go-http-helpers/h"
 	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/generator"
+	"github.com/tomek7667/secrets/internal/metrics"
+	"github.com/tomek7667/secrets/internal/secretsio"
 	"github.com/tomek7667/secrets/internal/sqlc"
 )
 
 type CreateSecretDto struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
+	// Generate makes the server generate the value instead of taking it from the request
+	Generate  *generator.Options `json:"generate"`
+	ExpiresAt *time.Time         `json:"expires_at"`
+	RotateBy  *time.Time         `json:"rotate_by"`
 }
 
 type UpdateSecretDto struct {
 	Value string `json:"value"`
 }
 
+// LeasedSecret is a secret read with a lease, the lease fields are empty otherwise
+type LeasedSecret struct {
+	sqlc.Secret
+	LeaseID        *string    `json:"lease_id"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
+}
+
+type UpdateSecretDatesDto struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	RotateBy  *time.Time `json:"rotate_by"`
+}
+
+type ImportResult struct {
+	DryRun  bool               `json:"dry_run"`
+	Changes []secretsio.Change `json:"changes"`
+}
+
+const (
+	maxImportSize          = 5 << 20
+	watchHeartbeatInterval = 15 * time.Second
+	defaultExpiringWithin  = 14 * 24 * time.Hour
+)
+
+// utc normalizes the dates saved in sqlite, so that they compare correctly as text
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// secretWarnings describes why a secret should no longer be used, they are returned in the Warning header
+func secretWarnings(secret sqlc.Secret, now time.Time) []string {
+	var warnings []string
+	if secret.ExpiresAt != nil && now.After(*secret.ExpiresAt) {
+		warnings = append(warnings, fmt.Sprintf(`299 - "secret expired at %s"`, secret.ExpiresAt.Format(time.RFC3339)))
+	}
+	if secret.RotateBy != nil && now.After(*secret.RotateBy) {
+		warnings = append(warnings, fmt.Sprintf(`299 - "secret is past its rotate-by date %s"`, secret.RotateBy.Format(time.RFC3339)))
+	}
+	return warnings
+}
+
 func getSupportedTokenTypes() []string {
 	return []string{"Api"}
 }
 
+// authorizeApiToken resolves the "Api <token>" authorization header together with the token permissions.
+// When it returns false, the response has already been written.
+func (s *Server) authorizeApiToken(w http.ResponseWriter, r *http.Request, action string) (sqlc.Token, permissionSet, bool) {
+	authValue := strings.TrimSpace(r.Header.Get("Authorization"))
+	token, found := strings.CutPrefix(authValue, "Api ")
+	if !found {
+		s.Log(UnauthorizedEvent, fmt.Sprintf("invalid token type '%s' provided to %s. Supported token types: %s", authValue, action, strings.Join(getSupportedTokenTypes(), ", ")), r)
+		h.ResUnauthorized(w)
+		return sqlc.Token{}, nil, false
+	}
+	tkn, err := s.Db.GetTokenByToken(r.Context(), token)
+	if err != nil {
+		s.Log(UnauthorizedEvent, fmt.Sprintf("invalid token '%s' provided to %s: %s", authValue, action, err.Error()), r)
+		h.ResUnauthorized(w)
+		return sqlc.Token{}, nil, false
+	}
+	permissions, err := s.permissionSet(r.Context(), tkn.ID)
+	if err != nil {
+		s.Log(ErrorEvent, fmt.Sprintf("failed to list permissions for token %s: %s", tkn.ID, err.Error()), r)
+		h.ResErr(w, err)
+		return sqlc.Token{}, nil, false
+	}
+	return tkn, permissions, true
+}
+
 func (s *Server) AddSecretsRoutes() {
 	auth := s.Router.With(chii.WithAuth(s.auther))
 	auth.Route("/api/secrets", func(r chi.Router) {
 		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 			user := chii.GetUser[sqlc.User](r)
-			secrets, err := s.Db.Queries.ListSecrets(r.Context())
+			secrets, err := s.Db.ListSecrets(r.Context())
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("failed to list secrets for user %s: %s", user.ID, err.Error()), r)
 				h.ResErr(w, err)
@@ -48,10 +128,24 @@ func (s *Server) AddSecretsRoutes() {
 				h.ResBadRequest(w, err)
 				return
 			}
-			secret, err := s.Db.Queries.CreateSecret(r.Context(), sqlc.CreateSecretParams{
-				ID:    utils.CreateUUID(),
-				Key:   dto.Key,
-				Value: utils.B64Encode(dto.Value),
+			value := dto.Value
+			if dto.Generate != nil {
+				if value != "" {
+					h.ResBadRequest(w, fmt.Errorf("either value or generate can be provided, not both"))
+					return
+				}
+				value, err = generator.Generate(*dto.Generate)
+				if err != nil {
+					h.ResBadRequest(w, err)
+					return
+				}
+			}
+			secret, err := s.Db.CreateSecret(r.Context(), sqlc.CreateSecretParams{
+				ID:        utils.CreateUUID(),
+				Key:       dto.Key,
+				Value:     utils.B64Encode(value),
+				ExpiresAt: utc(dto.ExpiresAt),
+				RotateBy:  utc(dto.RotateBy),
 			})
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to create secret %s: %s", user.ID, dto.Key, err.Error()), r)
@@ -60,6 +154,7 @@ func (s *Server) AddSecretsRoutes() {
 			} else {
 				s.Log(IngestEvent, fmt.Sprintf("user %s created secret %s", user.ID, dto.Key), r)
 			}
+			s.secretChanged(r.Context(), SecretCreatedEvent, secret.Key)
 			h.ResSuccess(w, secret)
 		})
 
@@ -71,134 +166,350 @@ func (s *Server) AddSecretsRoutes() {
 				h.ResBadRequest(w, err)
 				return
 			}
-			secret, err := s.Db.Queries.GetSecret(r.Context(), key)
-			if err != nil {
-				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update secret '%s' but an error happened: %s", user.ID, key, err.Error()), r)
+			var updatedSecret sqlc.Secret
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				secret, err := tx.GetSecret(r.Context(), key)
+				if err != nil {
+					return err
+				}
+				updatedSecret, err = tx.UpdateSecret(r.Context(), sqlc.UpdateSecretParams{
+					Key:   key,
+					Value: utils.B64Encode(dto.Value),
+				})
+				if err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), UpdateSecretEvent, fmt.Sprintf("user %s from %s to %s", user.ID, secret.Value, updatedSecret.Value))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update unexisting secret '%s'", user.ID, key), r)
 				h.ResNotFound(w, "secret")
 				return
 			}
-			updatedSecret, err := s.Db.Queries.UpdateSecret(r.Context(), sqlc.UpdateSecretParams{
-				Key:   key,
-				Value: utils.B64Encode(dto.Value),
-			})
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to update secret %s: %s", user.ID, key, err.Error()), r)
 				h.ResErr(w, err)
 				return
-			} else {
-				s.Log(UpdateSecretEvent, fmt.Sprintf("user %s from %s to %s", user.ID, secret.Value, updatedSecret.Value), r)
 			}
+			s.secretChanged(r.Context(), SecretUpdatedEvent, key)
 			h.ResSuccess(w, updatedSecret)
 		})
 
-		r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+		// Imports the request body in the given format, see secretsio.Parse
+		r.Post("/import", func(w http.ResponseWriter, r *http.Request) {
 			user := chii.GetUser[sqlc.User](r)
-			key := r.URL.Query().Get("key")
+			query := r.URL.Query()
+			policy, err := secretsio.ParseConflictPolicy(query.Get("conflict"))
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			dryRun := query.Get("dry_run") == "true"
+			data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+			if err != nil {
+				h.ResBadRequest(w, fmt.Errorf("failed to read the body: %w", err))
+				return
+			}
+			values, err := secretsio.Parse(secretsio.Format(query.Get("format")), data)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			var changes []secretsio.Change
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				changes, err = secretsio.ImportTx(r.Context(), tx, secretsio.WithPrefix(values, query.Get("prefix")), policy, dryRun)
+				if err != nil || dryRun {
+					return err
+				}
+				written := 0
+				for _, change := range changes {
+					if change.Action == secretsio.CreateAction || change.Action == secretsio.OverwriteAction {
+						written++
+					}
+				}
+				return tx.Log(r.Context(), IngestEvent, fmt.Sprintf("user %s imported %d of %d secrets", user.ID, written, len(changes)))
+			})
+			if errors.Is(err, secretsio.ErrConflict) {
+				h.ResBadRequest(w, err)
+				return
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to import secrets: %s", user.ID, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			if dryRun {
+				h.ResSuccess(w, ImportResult{DryRun: true, Changes: changes})
+				return
+			}
+			for _, change := range changes {
+				switch change.Action {
+				case secretsio.CreateAction:
+					s.secretChanged(r.Context(), SecretCreatedEvent, change.Key)
+				case secretsio.OverwriteAction:
+					s.secretChanged(r.Context(), SecretUpdatedEvent, change.Key)
+				}
+			}
+			h.ResSuccess(w, ImportResult{Changes: changes})
+		})
 
-			_, err := s.Db.Queries.GetSecret(r.Context(), key)
+		r.Put("/dates", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			key := r.URL.Query().Get("key")
+			dto, err := h.GetDto[UpdateSecretDatesDto](r)
 			if err != nil {
-				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting secret %s: %s", user.ID, key, err.Error()), r)
+				h.ResBadRequest(w, err)
+				return
+			}
+			var updatedSecret sqlc.Secret
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				_, err := tx.GetSecret(r.Context(), key)
+				if err != nil {
+					return err
+				}
+				updatedSecret, err = tx.UpdateSecretDates(r.Context(), sqlc.UpdateSecretDatesParams{
+					Key:       key,
+					ExpiresAt: utc(dto.ExpiresAt),
+					RotateBy:  utc(dto.RotateBy),
+				})
+				if err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), UpdateSecretEvent, fmt.Sprintf("user %s set dates of secret %s", user.ID, key))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update dates of unexisting secret '%s'", user.ID, key), r)
 				h.ResNotFound(w, "secret")
 				return
 			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to update dates of secret %s: %s", user.ID, key, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			h.ResSuccess(w, updatedSecret)
+		})
 
-			err = s.Db.Queries.DeleteSecret(r.Context(), key)
+		r.Get("/expiring", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			within := defaultExpiringWithin
+			if v := r.URL.Query().Get("within"); v != "" {
+				d, err := time.ParseDuration(v)
+				if err != nil {
+					h.ResBadRequest(w, fmt.Errorf("invalid duration '%s': %w", v, err))
+					return
+				}
+				within = d
+			}
+			before := time.Now().UTC().Add(within)
+			secrets, err := s.Db.ListExpiringSecrets(r.Context(), &before)
 			if err != nil {
-				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete secret %s: %s", user.ID, key, err.Error()), r)
+				s.Log(ErrorEvent, fmt.Sprintf("failed to list expiring secrets for user %s: %s", user.ID, err.Error()), r)
 				h.ResErr(w, err)
 				return
 			} else {
-				s.Log(DeleteEvent, fmt.Sprintf("user %s deleted secret %s", user.ID, key), r)
+				s.Log(GetSecretsEvent, fmt.Sprintf("%s retrieved secrets expiring within %s", user.ID, within), r)
 			}
+			h.ResSuccess(w, secrets)
+		})
+
+		r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			key := r.URL.Query().Get("key")
+
+			err := s.withTx(r.Context(), r, func(tx *Tx) error {
+				if _, err := tx.GetSecret(r.Context(), key); err != nil {
+					return err
+				}
+				if err := tx.DeleteRotationPolicyBySecretKey(r.Context(), key); err != nil {
+					return err
+				}
+				// the old values would show up in the history of a new secret of the key
+				if err := tx.DeleteSecretVersions(r.Context(), key); err != nil {
+					return err
+				}
+				if err := tx.DeleteSecret(r.Context(), key); err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), DeleteEvent, fmt.Sprintf("user %s deleted secret %s", user.ID, key))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting secret %s", user.ID, key), r)
+				h.ResNotFound(w, "secret")
+				return
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete secret %s: %s", user.ID, key, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			s.secretChanged(r.Context(), SecretDeletedEvent, key)
 			h.ResSuccess(w, nil)
 		})
 	})
 
 	s.Router.Get("/api/secrets/get", func(w http.ResponseWriter, r *http.Request) {
-		var err error
-		var tkn sqlc.Token
-		authValue := strings.TrimSpace(r.Header.Get("Authorization"))
 		key := r.URL.Query().Get("key")
-		if strings.HasPrefix(authValue, "Api ") {
-			token, _ := strings.CutPrefix(authValue, "Api ")
-			tkn, err = s.Db.Queries.GetTokenByToken(r.Context(), token)
-			if err != nil {
-				s.Log(UnauthorizedEvent, fmt.Sprintf("invalid token '%s' provided to get secret '%s': %s", authValue, key, err.Error()), r)
-				h.ResUnauthorized(w)
-				return
-			}
-		} else {
-			s.Log(UnauthorizedEvent, fmt.Sprintf("invalid token type '%s' provided to get secret '%s'. Supported token types: %s", authValue, key, strings.Join(getSupportedTokenTypes(), ", ")), r)
-			h.ResUnauthorized(w)
-			return
-		}
-		permissions, err := s.Db.Queries.ListPermissionsByTokenId(r.Context(), tkn.ID)
-		if err != nil {
-			s.Log(ErrorEvent, fmt.Sprintf("failed to list permissions for token %s: %s", tkn.ID, err.Error()), r)
-			h.ResErr(w, err)
+		tkn, permissions, ok := s.authorizeApiToken(w, r, fmt.Sprintf("get secret '%s'", key))
+		if !ok {
 			return
 		}
-		secret, err := s.Db.Queries.GetSecret(r.Context(), key)
+		secret, err := s.Db.GetSecret(r.Context(), key)
 		if err != nil {
 			s.Log(ErrorEvent, fmt.Sprintf("(before matching) couldn't retrieve secret %s for token %s: %s", tkn.ID, key, err.Error()), r)
 			h.ResErr(w, err)
 			return
 		}
-		matched := false
-		for _, permission := range permissions {
-			if PatternMatches(secret.Key, permission.SecretKeyPattern) {
-				matched = true
-				break
-			}
-		}
-		if !matched {
+		if !permissions.allows(secret.Key) {
 			s.Log(UnauthorizedEvent, fmt.Sprintf("token %s can't access %s", tkn.ID, key), r)
 			h.ResUnauthorized(w)
 			return
 		}
+		leased := LeasedSecret{Secret: secret}
+		if v := r.URL.Query().Get("lease"); v != "" {
+			ttl, err := time.ParseDuration(v)
+			if err != nil || ttl <= 0 {
+				h.ResBadRequest(w, fmt.Errorf("invalid lease duration '%s'", v))
+				return
+			}
+			lease, err := s.issueSecretLease(r.Context(), secret.Key, tkn.ID, ttl)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("failed to lease secret %s to token %s: %s", key, tkn.ID, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			leased.LeaseID = &lease.ID
+			leased.LeaseExpiresAt = &lease.ExpiresAt
+		}
+		for _, warning := range secretWarnings(secret, time.Now()) {
+			w.Header().Add("Warning", warning)
+		}
+		metrics.TokenReads.WithLabelValues(tkn.ID).Inc()
 		s.Log(GetSecretEvent, fmt.Sprintf("token %s", tkn.ID), r)
-		h.ResSuccess(w, secret)
+		h.ResSuccess(w, leased)
 	})
 
 	s.Router.Get("/api/secrets/list", func(w http.ResponseWriter, r *http.Request) {
+		tkn, permissions, ok := s.authorizeApiToken(w, r, "get env")
+		if !ok {
+			return
+		}
+		allowedSecrets, err := s.allowedSecrets(r.Context(), permissions)
+		if err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("couldn't retrieve secrets for token %s: %s", tkn.ID, err.Error()), r)
+			h.ResErr(w, err)
+			return
+		}
+		metrics.TokenReads.WithLabelValues(tkn.ID).Inc()
+		s.Log(GetFullEnvEvent, fmt.Sprintf("token %s retrieved %d secrets as env", tkn.ID, len(allowedSecrets)), r)
+		h.ResSuccess(w, allowedSecrets)
+	})
+
+	// Exports the secrets as a file, see secretsio.Export. Users export all the secrets,
+	// tokens only the ones their permissions allow.
+	s.Router.Get("/api/secrets/export", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		format := secretsio.Format(query.Get("format"))
+		var subject string
+		var secrets []sqlc.Secret
 		var err error
-		var tkn sqlc.Token
-		authValue := strings.TrimSpace(r.Header.Get("Authorization"))
-		if strings.HasPrefix(authValue, "Api ") {
-			token, _ := strings.CutPrefix(authValue, "Api ")
-			tkn, err = s.Db.Queries.GetTokenByToken(r.Context(), token)
-			if err != nil {
-				s.Log(UnauthorizedEvent, fmt.Sprintf("invalid token '%s' provided to get env: %s", authValue, err.Error()), r)
+		if bearer, found := strings.CutPrefix(strings.TrimSpace(r.Header.Get("Authorization")), "Bearer "); found {
+			user, authErr := s.auther.GetUserFromToken(r.Context(), bearer)
+			if authErr != nil {
+				s.Log(UnauthorizedEvent, fmt.Sprintf("invalid user token provided to export secrets: %s", authErr.Error()), r)
 				h.ResUnauthorized(w)
 				return
 			}
+			subject = "user " + user.ID
+			secrets, err = s.Db.ListSecrets(r.Context())
 		} else {
-			s.Log(UnauthorizedEvent, fmt.Sprintf("invalid token type '%s' provided to get env. Supported token types: %s", authValue, strings.Join(getSupportedTokenTypes(), ", ")), r)
-			h.ResUnauthorized(w)
-			return
+			tkn, permissions, ok := s.authorizeApiToken(w, r, "export secrets")
+			if !ok {
+				return
+			}
+			subject = "token " + tkn.ID
+			metrics.TokenReads.WithLabelValues(tkn.ID).Inc()
+			secrets, err = s.allowedSecrets(r.Context(), permissions)
 		}
-		permissions, err := s.Db.Queries.ListPermissionsByTokenId(r.Context(), tkn.ID)
 		if err != nil {
-			s.Log(ErrorEvent, fmt.Sprintf("failed to list permissions for token %s: %s", tkn.ID, err.Error()), r)
+			s.Log(ErrorEvent, fmt.Sprintf("couldn't retrieve secrets for %s export: %s", subject, err.Error()), r)
 			h.ResErr(w, err)
 			return
 		}
-		secrets, err := s.Db.Queries.ListSecrets(r.Context())
+		data, err := secretsio.Export(format, secrets, secretsio.ExportOptions{
+			Prefix:      query.Get("prefix"),
+			StripPrefix: query.Get("strip_prefix") == "true",
+			EnvNames:    query.Get("env_names") == "true",
+		})
 		if err != nil {
-			s.Log(ErrorEvent, fmt.Sprintf("(before matching) couldn't retrieve secrets for token %s: %s", tkn.ID, err.Error()), r)
-			h.ResErr(w, err)
+			h.ResBadRequest(w, err)
 			return
 		}
-		var allowedSecrets []sqlc.Secret
-		for _, secret := range secrets {
-			for _, permission := range permissions {
-				if PatternMatches(secret.Key, permission.SecretKeyPattern) {
-					allowedSecrets = append(allowedSecrets, secret)
-					break
+		s.Log(ExportSecretsEvent, fmt.Sprintf("%s exported secrets as %s", subject, format), r)
+		w.Header().Set("Content-Type", secretsio.ContentType(format))
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(data)
+	})
+	// Streams changes of the secrets the token can access as server-sent events.
+	// Permissions are re-checked on every heartbeat, so a revoked token stops receiving events.
+	s.Router.Get("/api/secrets/watch", func(w http.ResponseWriter, r *http.Request) {
+		tkn, permissions, ok := s.authorizeApiToken(w, r, "watch secrets")
+		if !ok {
+			return
+		}
+		pattern := r.URL.Query().Get("pattern")
+		if pattern == "" {
+			pattern = WildCardChar
+		}
+		rc := http.NewResponseController(w)
+		changes := s.watchHub.subscribe()
+		defer s.watchHub.unsubscribe(changes)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if err := rc.Flush(); err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("streaming is not supported for token %s: %s", tkn.ID, err.Error()), r)
+			return
+		}
+		s.Log(WatchSecretsEvent, fmt.Sprintf("token %s started watching %s", tkn.ID, pattern), r)
+
+		heartbeat := time.NewTicker(watchHeartbeatInterval)
+		defer heartbeat.Stop()
+		eventId := 0
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err := s.Db.GetToken(r.Context(), tkn.ID); err != nil {
+					s.Log(UnauthorizedEvent, fmt.Sprintf("token %s stopped watching: %s", tkn.ID, err.Error()), r)
+					return
+				}
+				refreshed, err := s.permissionSet(r.Context(), tkn.ID)
+				if err != nil {
+					s.Log(ErrorEvent, fmt.Sprintf("failed to list permissions for token %s: %s", tkn.ID, err.Error()), r)
+					return
+				}
+				permissions = refreshed
+				fmt.Fprint(w, ": heartbeat\n\n")
+			case change, open := <-changes:
+				if !open {
+					// the stream fell behind, the client reconnects and re-reads its secrets
+					return
+				}
+				if !PatternMatches(change.Key, pattern) || !permissions.allows(change.Key) {
+					continue
 				}
+				eventId++
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", eventId, change.Event, utils.MustMarshal(change))
+			}
+			if err := rc.Flush(); err != nil {
+				return
 			}
 		}
-		s.Log(GetFullEnvEvent, fmt.Sprintf("token %s retrieved %d secrets as env", tkn.ID, len(allowedSecrets)), r)
-		h.ResSuccess(w, allowedSecrets)
 	})
 }
@@ -1,6 +1,8 @@
 package secrets
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -25,7 +27,7 @@ func (s *Server) AddUsersRoutes() {
 	auth.Route("/api/users", func(r chi.Router) {
 		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 			user := chii.GetUser[sqlc.User](r)
-			users, err := s.Db.Queries.ListUsers(r.Context())
+			users, err := s.Db.ListUsers(r.Context())
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("failed to list users for user %s: %s", user.ID, err.Error()), r)
 				h.ResErr(w, err)
@@ -39,7 +41,7 @@ func (s *Server) AddUsersRoutes() {
 		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
 			user := chii.GetUser[sqlc.User](r)
 			id := chi.URLParam(r, "id")
-			fetchedUser, err := s.Db.Queries.GetUser(r.Context(), id)
+			fetchedUser, err := s.Db.GetUser(r.Context(), id)
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to get user %s: %s", user.ID, id, err.Error()), r)
 				h.ResNotFound(w, "user")
@@ -57,7 +59,7 @@ func (s *Server) AddUsersRoutes() {
 				h.ResBadRequest(w, err)
 				return
 			}
-			newuser, err := s.Db.Queries.CreateUser(r.Context(), sqlc.CreateUserParams{
+			newuser, err := s.Db.CreateUser(r.Context(), sqlc.CreateUserParams{
 				ID:       utils.CreateUUID(),
 				Username: dto.Username,
 				Password: dto.Password,
@@ -80,7 +82,7 @@ func (s *Server) AddUsersRoutes() {
 				h.ResBadRequest(w, err)
 				return
 			}
-			toBeUpdated, err := s.Db.Queries.UpdateUser(r.Context(), sqlc.UpdateUserParams{
+			toBeUpdated, err := s.Db.UpdateUser(r.Context(), sqlc.UpdateUserParams{
 				ID:       id,
 				Password: dto.Password,
 			})
@@ -97,20 +99,24 @@ func (s *Server) AddUsersRoutes() {
 		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
 			user := chii.GetUser[sqlc.User](r)
 			id := chi.URLParam(r, "id")
-			_, err := s.Db.Queries.GetUser(r.Context(), id)
-			if err != nil {
-				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting user %s: %s", user.ID, id, err.Error()), r)
+			err := s.withTx(r.Context(), r, func(tx *Tx) error {
+				if _, err := tx.GetUser(r.Context(), id); err != nil {
+					return err
+				}
+				if err := tx.DeleteUser(r.Context(), id); err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), DeleteEvent, fmt.Sprintf("user %s deleted user %s", user.ID, id))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting user %s", user.ID, id), r)
 				h.ResNotFound(w, "user")
 				return
 			}
-
-			err = s.Db.Queries.DeleteUser(r.Context(), id)
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete user %s: %s", user.ID, id, err.Error()), r)
 				h.ResErr(w, err)
 				return
-			} else {
-				s.Log(DeleteEvent, fmt.Sprintf("user %s deleted user %s", user.ID, id), r)
 			}
 			h.ResSuccess(w, nil)
 		})
@@ -1,6 +1,8 @@
 package secrets
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -26,7 +28,7 @@ func (s *Server) AddTokensRoutes() {
 	auth.Route("/api/tokens", func(r chi.Router) {
 		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 			user := chii.GetUser[sqlc.User](r)
-			tokens, err := s.Db.Queries.ListTokens(r.Context())
+			tokens, err := s.Db.ListTokens(r.Context())
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("failed to list tokens for user %s: %s", user.ID, err.Error()), r)
 				h.ResErr(w, err)
@@ -44,7 +46,7 @@ func (s *Server) AddTokensRoutes() {
 				h.ResBadRequest(w, err)
 				return
 			}
-			token, err := s.Db.Queries.CreateToken(r.Context(), sqlc.CreateTokenParams{
+			token, err := s.Db.CreateToken(r.Context(), sqlc.CreateTokenParams{
 				ID:        utils.CreateUUID(),
 				Token:     dto.Token,
 				ExpiresAt: dto.ExpiresAt,
@@ -67,22 +69,30 @@ func (s *Server) AddTokensRoutes() {
 				h.ResBadRequest(w, err)
 				return
 			}
-			token, err := s.Db.Queries.GetToken(r.Context(), id)
-			if err != nil {
-				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update token '%s' but an error happened: %s", user.ID, id, err.Error()), r)
+			var updatedToken sqlc.Token
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				token, err := tx.GetToken(r.Context(), id)
+				if err != nil {
+					return err
+				}
+				updatedToken, err = tx.UpdateToken(r.Context(), sqlc.UpdateTokenParams{
+					ID:        id,
+					ExpiresAt: dto.ExpiresAt,
+				})
+				if err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), UpdateTokenEvent, fmt.Sprintf("user %s from %s to %s", user.ID, token.ExpiresAt.Format(time.RFC3339), updatedToken.ExpiresAt.Format(time.RFC3339)))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update unexisting token '%s'", user.ID, id), r)
 				h.ResNotFound(w, "token")
 				return
 			}
-			updatedToken, err := s.Db.Queries.UpdateToken(r.Context(), sqlc.UpdateTokenParams{
-				ID:        id,
-				ExpiresAt: dto.ExpiresAt,
-			})
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to update token %s: %s", user.ID, id, err.Error()), r)
 				h.ResErr(w, err)
 				return
-			} else {
-				s.Log(UpdateTokenEvent, fmt.Sprintf("user %s from %s to %s", user.ID, token.ExpiresAt.Format(time.RFC3339), updatedToken.ExpiresAt.Format(time.RFC3339)), r)
 			}
 			h.ResSuccess(w, updatedToken)
 		})
@@ -90,20 +100,37 @@ func (s *Server) AddTokensRoutes() {
 		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
 			user := chii.GetUser[sqlc.User](r)
 			id := chi.URLParam(r, "id")
-			_, err := s.Db.Queries.GetToken(r.Context(), id)
+			// leases revoke outside the transaction, dynamic ones drop database users which can't be rolled back
+			leases, err := s.Db.ListActiveLeasesByTokenId(r.Context(), &id)
 			if err != nil {
-				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting token %s: %s", user.ID, id, err.Error()), r)
-				h.ResNotFound(w, "token")
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to list the leases of token %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			if _, err := s.revokeLeases(r.Context(), leases); err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete token %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
 				return
 			}
 
-			err = s.Db.Queries.DeleteToken(r.Context(), id)
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				if _, err := tx.GetToken(r.Context(), id); err != nil {
+					return err
+				}
+				if err := tx.DeleteToken(r.Context(), id); err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), DeleteEvent, fmt.Sprintf("user %s deleted token %s", user.ID, id))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting token %s", user.ID, id), r)
+				h.ResNotFound(w, "token")
+				return
+			}
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete token %s: %s", user.ID, id, err.Error()), r)
 				h.ResErr(w, err)
 				return
-			} else {
-				s.Log(DeleteEvent, fmt.Sprintf("user %s deleted token %s", user.ID, id), r)
 			}
 			h.ResSuccess(w, nil)
 		})
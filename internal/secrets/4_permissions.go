@@ -1,6 +1,8 @@
 package secrets
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -25,7 +27,7 @@ func (s *Server) AddPermissionsRoutes() {
 	auth.Route("/api/permissions", func(r chi.Router) {
 		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 			user := chii.GetUser[sqlc.User](r)
-			permissions, err := s.Db.Queries.ListPermissions(r.Context())
+			permissions, err := s.Db.ListPermissions(r.Context())
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("failed to list permissions for user %s: %s", user.ID, err.Error()), r)
 				h.ResErr(w, err)
@@ -43,22 +45,30 @@ func (s *Server) AddPermissionsRoutes() {
 				h.ResBadRequest(w, err)
 				return
 			}
-			_, err = s.Db.Queries.GetToken(r.Context(), dto.TokenID)
-			if err != nil {
+			var permission sqlc.Permission
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				_, err := tx.GetToken(r.Context(), dto.TokenID)
+				if err != nil {
+					return err
+				}
+				permission, err = tx.CreatePermission(r.Context(), sqlc.CreatePermissionParams{
+					ID:               utils.CreateUUID(),
+					TokenID:          dto.TokenID,
+					SecretKeyPattern: dto.SecretKeyPattern,
+				})
+				if err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), IngestEvent, fmt.Sprintf("user %s created permission %s", user.ID, permission.ID))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
 				h.ResNotFound(w, "specified token")
 				return
 			}
-			permission, err := s.Db.Queries.CreatePermission(r.Context(), sqlc.CreatePermissionParams{
-				ID:               utils.CreateUUID(),
-				TokenID:          dto.TokenID,
-				SecretKeyPattern: dto.SecretKeyPattern,
-			})
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to create permission %s for token %s: %s", user.ID, dto.SecretKeyPattern, dto.TokenID, err.Error()), r)
 				h.ResErr(w, err)
 				return
-			} else {
-				s.Log(IngestEvent, fmt.Sprintf("user %s created permission %s", user.ID, permission.ID), r)
 			}
 			h.ResSuccess(w, permission)
 		})
@@ -71,22 +81,30 @@ func (s *Server) AddPermissionsRoutes() {
 				h.ResBadRequest(w, err)
 				return
 			}
-			permission, err := s.Db.Queries.GetPermission(r.Context(), id)
-			if err != nil {
-				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update permission '%s' but an error happened: %s", user.ID, id, err.Error()), r)
+			var updatedPermission sqlc.Permission
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				permission, err := tx.GetPermission(r.Context(), id)
+				if err != nil {
+					return err
+				}
+				updatedPermission, err = tx.UpdatePermission(r.Context(), sqlc.UpdatePermissionParams{
+					ID:               id,
+					SecretKeyPattern: dto.SecretKeyPattern,
+				})
+				if err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), UpdatePermissionEvent, fmt.Sprintf("user %s from %s to %s", user.ID, permission.SecretKeyPattern, updatedPermission.SecretKeyPattern))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update unexisting permission '%s'", user.ID, id), r)
 				h.ResNotFound(w, "permission")
 				return
 			}
-			updatedPermission, err := s.Db.Queries.UpdatePermission(r.Context(), sqlc.UpdatePermissionParams{
-				ID:               id,
-				SecretKeyPattern: dto.SecretKeyPattern,
-			})
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to update permission %s: %s", user.ID, id, err.Error()), r)
 				h.ResErr(w, err)
 				return
-			} else {
-				s.Log(UpdatePermissionEvent, fmt.Sprintf("user %s from %s to %s", user.ID, permission.SecretKeyPattern, updatedPermission.SecretKeyPattern), r)
 			}
 			h.ResSuccess(w, updatedPermission)
 		})
@@ -94,20 +112,24 @@ func (s *Server) AddPermissionsRoutes() {
 		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
 			user := chii.GetUser[sqlc.User](r)
 			id := chi.URLParam(r, "id")
-			_, err := s.Db.Queries.GetPermission(r.Context(), id)
-			if err != nil {
-				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting permission %s: %s", user.ID, id, err.Error()), r)
+			err := s.withTx(r.Context(), r, func(tx *Tx) error {
+				if _, err := tx.GetPermission(r.Context(), id); err != nil {
+					return err
+				}
+				if err := tx.DeletePermission(r.Context(), id); err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), DeleteEvent, fmt.Sprintf("user %s deleted permission %s", user.ID, id))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting permission %s", user.ID, id), r)
 				h.ResNotFound(w, "permission")
 				return
 			}
-
-			err = s.Db.Queries.DeletePermission(r.Context(), id)
 			if err != nil {
 				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete permission %s: %s", user.ID, id, err.Error()), r)
 				h.ResErr(w, err)
 				return
-			} else {
-				s.Log(DeleteEvent, fmt.Sprintf("user %s deleted permission %s", user.ID, id), r)
 			}
 			h.ResSuccess(w, nil)
 		})
@@ -0,0 +1,239 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/tomek7667/go-http-helpers/chii"
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+type CreateWebhookDto struct {
+	Url           string   `json:"url"`
+	KeyPattern    string   `json:"key_pattern"`
+	Events        []string `json:"events"`
+	SigningSecret string   `json:"signing_secret"`
+}
+
+type UpdateWebhookDto struct {
+	Url           string   `json:"url"`
+	KeyPattern    string   `json:"key_pattern"`
+	Events        []string `json:"events"`
+	SigningSecret string   `json:"signing_secret"`
+}
+
+// parseWebhookEvents validates the subscribed events and joins them for storage.
+// No events means a subscription to all of them.
+func parseWebhookEvents(events []string) (string, error) {
+	if len(events) == 0 {
+		events = []string{SecretCreatedEvent.String(), SecretUpdatedEvent.String(), SecretDeletedEvent.String(), SecretRotatedEvent.String()}
+	}
+	for _, event := range events {
+		switch SecretEvent(event) {
+		case SecretCreatedEvent, SecretUpdatedEvent, SecretDeletedEvent, SecretRotatedEvent:
+		default:
+			return "", fmt.Errorf("unknown event '%s', supported events: %s, %s, %s, %s", event, SecretCreatedEvent, SecretUpdatedEvent, SecretDeletedEvent, SecretRotatedEvent)
+		}
+	}
+	return strings.Join(events, ","), nil
+}
+
+func validateWebhookUrl(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook url must use http or https")
+	}
+	return nil
+}
+
+func (s *Server) AddWebhooksRoutes() {
+	auth := s.Router.With(chii.WithAuth(s.auther))
+	auth.Route("/api/webhooks", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			webhooks, err := s.Db.ListWebhooks(r.Context())
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("failed to list webhooks for user %s: %s", user.ID, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(GetWebhooksEvent, fmt.Sprintf("%s retrieved webhooks", user.ID), r)
+			}
+			h.ResSuccess(w, webhooks)
+		})
+
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			webhook, err := s.Db.GetWebhook(r.Context(), id)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to get webhook %s: %s", user.ID, id, err.Error()), r)
+				h.ResNotFound(w, "webhook")
+				return
+			} else {
+				s.Log(GetWebhooksEvent, fmt.Sprintf("%s retrieved webhook %s", user.ID, id), r)
+			}
+			h.ResSuccess(w, webhook)
+		})
+
+		r.Get("/{id}/deliveries", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			limit := int64(100)
+			if l := r.URL.Query().Get("limit"); l != "" {
+				parsed, err := strconv.ParseInt(l, 10, 64)
+				if err != nil || parsed <= 0 {
+					h.ResBadRequest(w, fmt.Errorf("limit must be a positive integer"))
+					return
+				}
+				limit = parsed
+			}
+			_, err := s.Db.GetWebhook(r.Context(), id)
+			if err != nil {
+				h.ResNotFound(w, "webhook")
+				return
+			}
+			deliveries, err := s.Db.ListWebhookDeliveries(r.Context(), sqlc.ListWebhookDeliveriesParams{
+				WebhookID: id,
+				Limit:     limit,
+			})
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to list deliveries of webhook %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(GetWebhooksEvent, fmt.Sprintf("%s retrieved deliveries of webhook %s", user.ID, id), r)
+			}
+			h.ResSuccess(w, deliveries)
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			dto, err := h.GetDto[CreateWebhookDto](r)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			if err := validateWebhookUrl(dto.Url); err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			events, err := parseWebhookEvents(dto.Events)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			signingSecret := dto.SigningSecret
+			if signingSecret == "" {
+				signingSecret = rand.Text()
+			}
+			webhook, err := s.Db.CreateWebhook(r.Context(), sqlc.CreateWebhookParams{
+				ID:            utils.CreateUUID(),
+				Url:           dto.Url,
+				KeyPattern:    dto.KeyPattern,
+				Events:        events,
+				SigningSecret: signingSecret,
+			})
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to create webhook %s: %s", user.ID, dto.Url, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(IngestEvent, fmt.Sprintf("user %s created webhook %s for %s", user.ID, webhook.ID, webhook.KeyPattern), r)
+			}
+			h.ResSuccess(w, webhook)
+		})
+
+		r.Put("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			dto, err := h.GetDto[UpdateWebhookDto](r)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			if err := validateWebhookUrl(dto.Url); err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			events, err := parseWebhookEvents(dto.Events)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			var updatedWebhook sqlc.Webhook
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				webhook, err := tx.GetWebhook(r.Context(), id)
+				if err != nil {
+					return err
+				}
+				signingSecret := dto.SigningSecret
+				if signingSecret == "" {
+					signingSecret = webhook.SigningSecret
+				}
+				updatedWebhook, err = tx.UpdateWebhook(r.Context(), sqlc.UpdateWebhookParams{
+					ID:            id,
+					Url:           dto.Url,
+					KeyPattern:    dto.KeyPattern,
+					Events:        events,
+					SigningSecret: signingSecret,
+				})
+				if err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), UpdateWebhookEvent, fmt.Sprintf("user %s from %s %s to %s %s", user.ID, webhook.Url, webhook.KeyPattern, updatedWebhook.Url, updatedWebhook.KeyPattern))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update unexisting webhook '%s'", user.ID, id), r)
+				h.ResNotFound(w, "webhook")
+				return
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to update webhook %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			h.ResSuccess(w, updatedWebhook)
+		})
+
+		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			err := s.withTx(r.Context(), r, func(tx *Tx) error {
+				if _, err := tx.GetWebhook(r.Context(), id); err != nil {
+					return err
+				}
+				if err := tx.DeleteWebhookDeliveries(r.Context(), id); err != nil {
+					return err
+				}
+				if err := tx.DeleteWebhook(r.Context(), id); err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), DeleteEvent, fmt.Sprintf("user %s deleted webhook %s", user.ID, id))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting webhook %s", user.ID, id), r)
+				h.ResNotFound(w, "webhook")
+				return
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete webhook %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			h.ResSuccess(w, nil)
+		})
+	})
+}
@@ -0,0 +1,231 @@
+package secrets
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/tomek7667/go-http-helpers/chii"
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/generator"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+type CreateRotationPolicyDto struct {
+	SecretKey string            `json:"secret_key"`
+	Interval  string            `json:"interval"`
+	Generator generator.Options `json:"generator"`
+}
+
+type UpdateRotationPolicyDto struct {
+	Interval  string            `json:"interval"`
+	Generator generator.Options `json:"generator"`
+}
+
+// parseRotationPolicy validates the interval (a go duration, e.g. "720h") and the
+// generator options by generating a throwaway value.
+func parseRotationPolicy(interval string, opts generator.Options) (time.Duration, string, error) {
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid interval '%s': %w", interval, err)
+	}
+	if d < minRotationInterval {
+		return 0, "", fmt.Errorf("interval must be at least %s", minRotationInterval)
+	}
+	if _, err := generator.Generate(opts); err != nil {
+		return 0, "", err
+	}
+	return d, utils.MustMarshal(opts), nil
+}
+
+func (s *Server) AddRotationsRoutes() {
+	auth := s.Router.With(chii.WithAuth(s.auther))
+	auth.Route("/api/rotations", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			policies, err := s.Db.ListRotationPolicies(r.Context())
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("failed to list rotation policies for user %s: %s", user.ID, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(GetRotationsEvent, fmt.Sprintf("%s retrieved rotation policies", user.ID), r)
+			}
+			h.ResSuccess(w, policies)
+		})
+
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			policy, err := s.Db.GetRotationPolicy(r.Context(), id)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to get rotation policy %s: %s", user.ID, id, err.Error()), r)
+				h.ResNotFound(w, "rotation policy")
+				return
+			} else {
+				s.Log(GetRotationsEvent, fmt.Sprintf("%s retrieved rotation policy %s", user.ID, id), r)
+			}
+			h.ResSuccess(w, policy)
+		})
+
+		r.Get("/{id}/versions", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			policy, err := s.Db.GetRotationPolicy(r.Context(), id)
+			if err != nil {
+				h.ResNotFound(w, "rotation policy")
+				return
+			}
+			versions, err := s.Db.ListSecretVersions(r.Context(), policy.SecretKey)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to list versions of secret %s: %s", user.ID, policy.SecretKey, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(GetSecretsEvent, fmt.Sprintf("%s retrieved versions of secret %s", user.ID, policy.SecretKey), r)
+			}
+			h.ResSuccess(w, versions)
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			dto, err := h.GetDto[CreateRotationPolicyDto](r)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			interval, opts, err := parseRotationPolicy(dto.Interval, dto.Generator)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			var policy sqlc.RotationPolicy
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				_, err := tx.GetSecret(r.Context(), dto.SecretKey)
+				if err != nil {
+					return err
+				}
+				policy, err = tx.CreateRotationPolicy(r.Context(), sqlc.CreateRotationPolicyParams{
+					ID:              utils.CreateUUID(),
+					SecretKey:       dto.SecretKey,
+					IntervalSeconds: int64(interval.Seconds()),
+					Generator:       opts,
+					NextRotationAt:  time.Now().UTC().Add(interval),
+				})
+				if err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), IngestEvent, fmt.Sprintf("user %s created rotation policy %s for %s", user.ID, policy.ID, policy.SecretKey))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				h.ResNotFound(w, "specified secret")
+				return
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to create rotation policy for %s: %s", user.ID, dto.SecretKey, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			h.ResSuccess(w, policy)
+		})
+
+		r.Put("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			dto, err := h.GetDto[UpdateRotationPolicyDto](r)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			interval, opts, err := parseRotationPolicy(dto.Interval, dto.Generator)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			var updatedPolicy sqlc.RotationPolicy
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				policy, err := tx.GetRotationPolicy(r.Context(), id)
+				if err != nil {
+					return err
+				}
+				lastRotation := policy.CreatedAt
+				if policy.LastRotatedAt != nil {
+					lastRotation = policy.LastRotatedAt
+				}
+				nextRotationAt := time.Now().UTC().Add(interval)
+				if lastRotation != nil {
+					nextRotationAt = lastRotation.UTC().Add(interval)
+				}
+				updatedPolicy, err = tx.UpdateRotationPolicy(r.Context(), sqlc.UpdateRotationPolicyParams{
+					ID:              id,
+					IntervalSeconds: int64(interval.Seconds()),
+					Generator:       opts,
+					NextRotationAt:  nextRotationAt,
+				})
+				if err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), UpdateRotationEvent, fmt.Sprintf("user %s from every %ds to every %ds", user.ID, policy.IntervalSeconds, updatedPolicy.IntervalSeconds))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update unexisting rotation policy '%s'", user.ID, id), r)
+				h.ResNotFound(w, "rotation policy")
+				return
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to update rotation policy %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			h.ResSuccess(w, updatedPolicy)
+		})
+
+		r.Post("/{id}/rotate", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			policy, err := s.Db.GetRotationPolicy(r.Context(), id)
+			if err != nil {
+				h.ResNotFound(w, "rotation policy")
+				return
+			}
+			secret, err := s.rotateSecret(r.Context(), policy)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to rotate secret %s: %s", user.ID, policy.SecretKey, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(RotateSecretEvent, fmt.Sprintf("user %s rotated secret %s", user.ID, policy.SecretKey), r)
+			}
+			h.ResSuccess(w, secret)
+		})
+
+		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			err := s.withTx(r.Context(), r, func(tx *Tx) error {
+				if _, err := tx.GetRotationPolicy(r.Context(), id); err != nil {
+					return err
+				}
+				if err := tx.DeleteRotationPolicy(r.Context(), id); err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), DeleteEvent, fmt.Sprintf("user %s deleted rotation policy %s", user.ID, id))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting rotation policy %s", user.ID, id), r)
+				h.ResNotFound(w, "rotation policy")
+				return
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete rotation policy %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			h.ResSuccess(w, nil)
+		})
+	})
+}
@@ -0,0 +1,233 @@
+package secrets
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/tomek7667/go-http-helpers/chii"
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/dynamic"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+type CreateDynamicRoleDto struct {
+	Name       string          `json:"name"`
+	Engine     dynamic.Kind    `json:"engine"`
+	Config     json.RawMessage `json:"config"`
+	DefaultTtl string          `json:"default_ttl"`
+	MaxTtl     string          `json:"max_ttl"`
+}
+
+type UpdateDynamicRoleDto struct {
+	Config     json.RawMessage `json:"config"`
+	DefaultTtl string          `json:"default_ttl"`
+	MaxTtl     string          `json:"max_ttl"`
+}
+
+// parseDynamicRole validates the engine config and the ttls (go durations, e.g. "1h")
+func parseDynamicRole(kind dynamic.Kind, config json.RawMessage, defaultTtl, maxTtl string) (time.Duration, time.Duration, error) {
+	if _, err := dynamic.New(kind, string(config)); err != nil {
+		return 0, 0, err
+	}
+	d, err := time.ParseDuration(defaultTtl)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid default_ttl '%s': %w", defaultTtl, err)
+	}
+	m, err := time.ParseDuration(maxTtl)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid max_ttl '%s': %w", maxTtl, err)
+	}
+	if d < time.Minute || m < d {
+		return 0, 0, fmt.Errorf("default_ttl must be at least 1m and max_ttl at least default_ttl")
+	}
+	return d, m, nil
+}
+
+func (s *Server) AddDynamicRoutes() {
+	auth := s.Router.With(chii.WithAuth(s.auther))
+	auth.Route("/api/dynamic-roles", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			roles, err := s.Db.ListDynamicRoles(r.Context())
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("failed to list dynamic roles for user %s: %s", user.ID, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(GetDynamicRolesEvent, fmt.Sprintf("%s retrieved dynamic roles", user.ID), r)
+			}
+			h.ResSuccess(w, roles)
+		})
+
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			role, err := s.Db.GetDynamicRole(r.Context(), id)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to get dynamic role %s: %s", user.ID, id, err.Error()), r)
+				h.ResNotFound(w, "dynamic role")
+				return
+			} else {
+				s.Log(GetDynamicRolesEvent, fmt.Sprintf("%s retrieved dynamic role %s", user.ID, id), r)
+			}
+			h.ResSuccess(w, role)
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			dto, err := h.GetDto[CreateDynamicRoleDto](r)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			if err := dynamic.ValidateRoleName(dto.Name); err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			defaultTtl, maxTtl, err := parseDynamicRole(dto.Engine, dto.Config, dto.DefaultTtl, dto.MaxTtl)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			role, err := s.Db.CreateDynamicRole(r.Context(), sqlc.CreateDynamicRoleParams{
+				ID:                utils.CreateUUID(),
+				Name:              dto.Name,
+				Engine:            string(dto.Engine),
+				Config:            string(dto.Config),
+				DefaultTtlSeconds: int64(defaultTtl.Seconds()),
+				MaxTtlSeconds:     int64(maxTtl.Seconds()),
+			})
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to create dynamic role %s: %s", user.ID, dto.Name, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(IngestEvent, fmt.Sprintf("user %s created dynamic role %s", user.ID, role.Name), r)
+			}
+			h.ResSuccess(w, role)
+		})
+
+		r.Put("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			dto, err := h.GetDto[UpdateDynamicRoleDto](r)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			// the config is validated against the engine of the stored role
+			var updatedRole sqlc.DynamicRole
+			var invalid error
+			err = s.withTx(r.Context(), r, func(tx *Tx) error {
+				role, err := tx.GetDynamicRole(r.Context(), id)
+				if err != nil {
+					return err
+				}
+				defaultTtl, maxTtl, err := parseDynamicRole(dynamic.Kind(role.Engine), dto.Config, dto.DefaultTtl, dto.MaxTtl)
+				if err != nil {
+					invalid = err
+					return err
+				}
+				updatedRole, err = tx.UpdateDynamicRole(r.Context(), sqlc.UpdateDynamicRoleParams{
+					ID:                id,
+					Config:            string(dto.Config),
+					DefaultTtlSeconds: int64(defaultTtl.Seconds()),
+					MaxTtlSeconds:     int64(maxTtl.Seconds()),
+				})
+				if err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), UpdateDynamicRoleEvent, fmt.Sprintf("user %s updated dynamic role %s", user.ID, role.Name))
+			})
+			if invalid != nil {
+				h.ResBadRequest(w, invalid)
+				return
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to update unexisting dynamic role '%s'", user.ID, id), r)
+				h.ResNotFound(w, "dynamic role")
+				return
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to update dynamic role %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			h.ResSuccess(w, updatedRole)
+		})
+
+		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			role, err := s.Db.GetDynamicRole(r.Context(), id)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting dynamic role %s: %s", user.ID, id, err.Error()), r)
+				h.ResNotFound(w, "dynamic role")
+				return
+			}
+			// credentials must not outlive the role that knows how to revoke them
+			leases, err := s.Db.ListActiveLeasesByRoleId(r.Context(), &id)
+			if err != nil {
+				h.ResErr(w, err)
+				return
+			}
+			if _, err := s.revokeLeases(r.Context(), leases); err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete dynamic role %s: %s", user.ID, role.Name, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+
+			err = s.Db.DeleteDynamicRole(r.Context(), id)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete dynamic role %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(DeleteEvent, fmt.Sprintf("user %s deleted dynamic role %s and revoked %d leases", user.ID, role.Name, len(leases)), r)
+			}
+			h.ResSuccess(w, nil)
+		})
+	})
+
+	// Issues credentials of a role to tokens with a permission matching "dynamic/<role>"
+	s.Router.Get("/api/dynamic/creds", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("role")
+		tkn, permissions, ok := s.authorizeApiToken(w, r, fmt.Sprintf("get credentials of role '%s'", name))
+		if !ok {
+			return
+		}
+		if !permissions.allows("dynamic/" + name) {
+			s.Log(UnauthorizedEvent, fmt.Sprintf("token %s can't get credentials of role %s", tkn.ID, name), r)
+			h.ResUnauthorized(w)
+			return
+		}
+		var ttl time.Duration
+		if v := r.URL.Query().Get("ttl"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				h.ResBadRequest(w, fmt.Errorf("invalid ttl '%s': %w", v, err))
+				return
+			}
+			ttl = d
+		}
+		role, err := s.Db.GetDynamicRoleByName(r.Context(), name)
+		if err != nil {
+			h.ResNotFound(w, "dynamic role")
+			return
+		}
+		lease, err := s.issueLease(r.Context(), role, &tkn.ID, ttl)
+		if err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("failed to issue credentials of role %s to token %s: %s", name, tkn.ID, err.Error()), r)
+			h.ResErr(w, err)
+			return
+		}
+		s.Log(IssueLeaseEvent, fmt.Sprintf("token %s leased %s of role %s until %s", tkn.ID, leaseSubject(lease.Lease), name, lease.ExpiresAt.Format(time.RFC3339)), r)
+		h.ResSuccess(w, lease)
+	})
+}
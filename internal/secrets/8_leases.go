@@ -0,0 +1,148 @@
+package secrets
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/tomek7667/go-http-helpers/chii"
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func (s *Server) AddLeasesRoutes() {
+	auth := s.Router.With(chii.WithAuth(s.auther))
+	auth.Route("/api/leases", func(r chi.Router) {
+		// Lists all leases, or the active leases of a secret or token when secret_key or token_id is given
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			secretKey := r.URL.Query().Get("secret_key")
+			tokenID := r.URL.Query().Get("token_id")
+			var leases []sqlc.Lease
+			var err error
+			switch {
+			case secretKey != "":
+				leases, err = s.Db.ListActiveLeasesBySecretKey(r.Context(), &secretKey)
+			case tokenID != "":
+				leases, err = s.Db.ListActiveLeasesByTokenId(r.Context(), &tokenID)
+			default:
+				leases, err = s.Db.ListLeases(r.Context())
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("failed to list leases for user %s: %s", user.ID, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(GetLeasesEvent, fmt.Sprintf("%s retrieved leases", user.ID), r)
+			}
+			h.ResSuccess(w, leases)
+		})
+
+		// Revokes all the active leases of a secret or a token
+		r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			secretKey := r.URL.Query().Get("secret_key")
+			tokenID := r.URL.Query().Get("token_id")
+			var leases []sqlc.Lease
+			var err error
+			var subject string
+			switch {
+			case secretKey != "":
+				subject = "secret " + secretKey
+				leases, err = s.Db.ListActiveLeasesBySecretKey(r.Context(), &secretKey)
+			case tokenID != "":
+				subject = "token " + tokenID
+				leases, err = s.Db.ListActiveLeasesByTokenId(r.Context(), &tokenID)
+			default:
+				h.ResBadRequest(w, fmt.Errorf("either secret_key or token_id is required"))
+				return
+			}
+			if err != nil {
+				h.ResErr(w, err)
+				return
+			}
+			revoked, err := s.revokeLeases(r.Context(), leases)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s revoked %d of %d leases of %s: %s", user.ID, revoked, len(leases), subject, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(RevokeLeaseEvent, fmt.Sprintf("user %s revoked %d leases of %s", user.ID, revoked, subject), r)
+			}
+			h.ResSuccess(w, map[string]int{"revoked": revoked})
+		})
+
+		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			lease, err := s.Db.GetLease(r.Context(), id)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to revoke unexisting lease %s: %s", user.ID, id, err.Error()), r)
+				h.ResNotFound(w, "lease")
+				return
+			}
+			if lease.RevokedAt != nil {
+				h.ResSuccess(w, lease)
+				return
+			}
+			revokedLease, err := s.revokeLease(r.Context(), lease)
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to revoke lease %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(RevokeLeaseEvent, fmt.Sprintf("user %s revoked lease %s of %s", user.ID, id, leaseSubject(lease)), r)
+			}
+			h.ResSuccess(w, revokedLease)
+		})
+	})
+
+	// Extends a secret lease of the token. Leases of secrets that changed since they were read
+	// can't be renewed, the secret has to be read again.
+	s.Router.Post("/api/leases/{id}/renew", func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		tkn, permissions, ok := s.authorizeApiToken(w, r, fmt.Sprintf("renew lease '%s'", id))
+		if !ok {
+			return
+		}
+		ttl, err := time.ParseDuration(r.URL.Query().Get("ttl"))
+		if err != nil || ttl <= 0 {
+			h.ResBadRequest(w, fmt.Errorf("invalid ttl '%s'", r.URL.Query().Get("ttl")))
+			return
+		}
+		lease, err := s.Db.GetLease(r.Context(), id)
+		if err != nil || lease.TokenID == nil || *lease.TokenID != tkn.ID {
+			h.ResNotFound(w, "lease")
+			return
+		}
+		switch {
+		case lease.SecretKey == nil:
+			h.ResBadRequest(w, fmt.Errorf("dynamic credentials can't be renewed, request new ones"))
+			return
+		case lease.RevokedAt != nil || lease.ExpiresAt.Before(time.Now()):
+			h.ResBadRequest(w, fmt.Errorf("lease expired or was revoked"))
+			return
+		case lease.StaleAt != nil:
+			h.ResBadRequest(w, fmt.Errorf("secret changed since the lease was issued"))
+			return
+		}
+		// the read was allowed when the lease was issued, the permission may be gone since
+		if !permissions.allows(*lease.SecretKey) {
+			s.Log(UnauthorizedEvent, fmt.Sprintf("token %s can't renew lease %s of %s", tkn.ID, id, *lease.SecretKey), r)
+			h.ResUnauthorized(w)
+			return
+		}
+		renewedLease, err := s.Db.RenewLease(r.Context(), sqlc.RenewLeaseParams{
+			ID:        id,
+			ExpiresAt: time.Now().UTC().Add(min(ttl, maxSecretLeaseTtl)),
+		})
+		if err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("token %s failed to renew lease %s: %s", tkn.ID, id, err.Error()), r)
+			h.ResErr(w, err)
+			return
+		}
+		s.Log(RenewLeaseEvent, fmt.Sprintf("token %s renewed lease %s of %s until %s", tkn.ID, id, leaseSubject(lease), renewedLease.ExpiresAt.Format(time.RFC3339)), r)
+		h.ResSuccess(w, renewedLease)
+	})
+}
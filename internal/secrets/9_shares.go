@@ -0,0 +1,209 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/tomek7667/go-http-helpers/chii"
+	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+const (
+	defaultShareExpiry = 24 * time.Hour
+	maxShareExpiry     = 30 * 24 * time.Hour
+	maxShareViews      = 100
+)
+
+type CreateShareDto struct {
+	Value string `json:"value"`
+	// MaxViews defaults to 1
+	MaxViews int64 `json:"max_views"`
+	// ExpiresIn is a go duration, defaults to 24h
+	ExpiresIn string `json:"expires_in"`
+}
+
+type CreatedShare struct {
+	sqlc.Share
+	// Url carries the decryption key in its fragment, it's returned only once and never saved.
+	// It's relative unless the server has a public url, see Server.SetPublicUrl
+	Url string `json:"url"`
+}
+
+type RevealedShare struct {
+	Ciphertext string `json:"ciphertext"`
+	Nonce      string `json:"nonce"`
+	Views      int64  `json:"views"`
+	MaxViews   int64  `json:"max_views"`
+}
+
+// sealShare encrypts the value with a new AES-256-GCM key, the share id is authenticated as additional data
+func sealShare(id, value string) (key, nonce, ciphertext []byte, err error) {
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to generate a key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to generate a nonce: %w", err)
+	}
+	return key, nonce, gcm.Seal(nil, nonce, []byte(value), []byte(id)), nil
+}
+
+// SetPublicUrl sets the url the server is reached at, e.g. https://secrets.example.com, which share links are built from.
+// The request's Host can't be trusted with it: the client picks it and cluster forwarding rewrites it to the leader's.
+func (s *Server) SetPublicUrl(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("invalid public url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("public url must be an absolute http or https url, got '%s'", rawUrl)
+	}
+	s.publicUrl = strings.TrimSuffix(u.String(), "/")
+	return nil
+}
+
+// shareUrl is relative without a public url, the dashboard resolves it against its own origin
+func (s *Server) shareUrl(id string, key []byte) string {
+	return fmt.Sprintf("%s/s/%s#%s", s.publicUrl, id, base64.RawURLEncoding.EncodeToString(key))
+}
+
+func (s *Server) AddSharesRoutes() {
+	auth := s.Router.With(chii.WithAuth(s.auther))
+	auth.Route("/api/shares", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			shares, err := s.Db.ListShares(r.Context())
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("failed to list shares for user %s: %s", user.ID, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(GetSharesEvent, fmt.Sprintf("%s retrieved shares", user.ID), r)
+			}
+			h.ResSuccess(w, shares)
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			dto, err := h.GetDto[CreateShareDto](r)
+			if err != nil {
+				h.ResBadRequest(w, err)
+				return
+			}
+			if dto.Value == "" {
+				h.ResBadRequest(w, fmt.Errorf("value is required"))
+				return
+			}
+			if dto.MaxViews == 0 {
+				dto.MaxViews = 1
+			}
+			if dto.MaxViews < 0 || dto.MaxViews > maxShareViews {
+				h.ResBadRequest(w, fmt.Errorf("max_views must be between 1 and %d", maxShareViews))
+				return
+			}
+			expiresIn := defaultShareExpiry
+			if dto.ExpiresIn != "" {
+				expiresIn, err = time.ParseDuration(dto.ExpiresIn)
+				if err != nil || expiresIn <= 0 || expiresIn > maxShareExpiry {
+					h.ResBadRequest(w, fmt.Errorf("expires_in must be a duration up to %s", maxShareExpiry))
+					return
+				}
+			}
+			id := utils.CreateUUID()
+			key, nonce, ciphertext, err := sealShare(id, dto.Value)
+			if err != nil {
+				h.ResErr(w, err)
+				return
+			}
+			share, err := s.Db.CreateShare(r.Context(), sqlc.CreateShareParams{
+				ID:         id,
+				CreatedBy:  user.ID,
+				Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+				Nonce:      base64.StdEncoding.EncodeToString(nonce),
+				MaxViews:   dto.MaxViews,
+				ExpiresAt:  time.Now().UTC().Add(expiresIn),
+			})
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s tried to create a share: %s", user.ID, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			} else {
+				s.Log(CreateShareEvent, fmt.Sprintf("user %s created share %s for %d views until %s", user.ID, id, share.MaxViews, share.ExpiresAt.Format(time.RFC3339)), r)
+			}
+			h.ResSuccess(w, CreatedShare{Share: share, Url: s.shareUrl(id, key)})
+		})
+
+		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			user := chii.GetUser[sqlc.User](r)
+			id := chi.URLParam(r, "id")
+			err := s.withTx(r.Context(), r, func(tx *Tx) error {
+				if _, err := tx.GetShare(r.Context(), id); err != nil {
+					return err
+				}
+				if err := tx.DeleteShare(r.Context(), id); err != nil {
+					return err
+				}
+				return tx.Log(r.Context(), DeleteEvent, fmt.Sprintf("user %s deleted share %s", user.ID, id))
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete unexisting share %s", user.ID, id), r)
+				h.ResNotFound(w, "share")
+				return
+			}
+			if err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("user %s failed to delete share %s: %s", user.ID, id, err.Error()), r)
+				h.ResErr(w, err)
+				return
+			}
+			h.ResSuccess(w, nil)
+		})
+	})
+
+	// Public: counts a view and returns the ciphertext, which the /s/{id} page decrypts with the key from
+	// the url fragment. The share is burnt after its last view. It's a POST, so that link previews don't use up views.
+	s.Router.With(withRateLimit(s.shareLimiter)).Post("/api/shares/{id}/reveal", func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		share, err := s.Db.ViewShare(r.Context(), sqlc.ViewShareParams{
+			ID:  id,
+			Now: time.Now().UTC(),
+		})
+		if err != nil {
+			s.Log(UnauthorizedEvent, fmt.Sprintf("reveal of unexisting, expired or used up share %s", id), r)
+			h.ResNotFound(w, "share")
+			return
+		}
+		if share.Views >= share.MaxViews {
+			if err := s.Db.DeleteShare(r.Context(), id); err != nil {
+				s.Log(ErrorEvent, fmt.Sprintf("failed to burn share %s: %s", id, err.Error()), r)
+			}
+		}
+		s.Log(RevealShareEvent, fmt.Sprintf("share %s revealed (%d/%d views)", id, share.Views, share.MaxViews), r)
+		h.ResSuccess(w, RevealedShare{
+			Ciphertext: share.Ciphertext,
+			Nonce:      share.Nonce,
+			Views:      share.Views,
+			MaxViews:   share.MaxViews,
+		})
+	})
+}
@@ -0,0 +1,57 @@
+package secrets_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestConsistency(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	jwt := "Bearer " + adminJwt(t, db)
+	check := func() secrets.ConsistencyReport {
+		var report secrets.ConsistencyReport
+		if code := serve(srv, http.MethodGet, "/api/admin/consistency", jwt, "", &report); code != http.StatusOK {
+			t.Fatalf("expected the consistency report, got %d", code)
+		}
+		return report
+	}
+
+	if _, err := db.CreateToken(ctx, sqlc.CreateTokenParams{ID: "t1", Token: "token"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: "p1", TokenID: "t1", SecretKeyPattern: "app/*"}); err != nil {
+		t.Fatal(err)
+	}
+	if report := check(); !report.Consistent || len(report.OrphanedPermissions) != 0 || len(report.ForeignKeyViolations) != 0 {
+		t.Fatalf("expected a consistent db, got %+v", report)
+	}
+
+	// the writer has a single connection, so that the pragma applies to the insert, like on a db written before foreign keys
+	if _, err := db.DB.ExecContext(ctx, "PRAGMA foreign_keys=OFF"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: "p2", TokenID: "deleted", SecretKeyPattern: "app/*"})
+	if _, err := db.DB.ExecContext(ctx, "PRAGMA foreign_keys=ON"); err != nil {
+		t.Fatal(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := check()
+	if report.Consistent {
+		t.Error("expected the orphaned permission to make the db inconsistent")
+	}
+	if len(report.OrphanedPermissions) != 1 || report.OrphanedPermissions[0].ID != "p2" {
+		t.Errorf("expected the orphaned permission p2, got %+v", report.OrphanedPermissions)
+	}
+	if len(report.ForeignKeyViolations) != 1 || report.ForeignKeyViolations[0].Table != "permission" || report.ForeignKeyViolations[0].Parent != "token" {
+		t.Errorf("expected a violation of the permission token foreign key, got %+v", report.ForeignKeyViolations)
+	}
+}
@@ -8,11 +8,11 @@ import (
 
 	"github.com/tomek7667/go-http-helpers/utils"
 	"github.com/tomek7667/secrets/internal/sqlc"
-	"github.com/tomek7667/secrets/internal/sqlite"
+	"github.com/tomek7667/secrets/internal/storage"
 )
 
 type Auther struct {
-	Db        *sqlite.Client
+	Db        storage.Store
 	JwtSecret string
 }
 
@@ -21,7 +21,7 @@ func (a Auther) GetUserFromToken(ctx context.Context, token string) (*sqlc.User,
 	if err != nil {
 		return nil, fmt.Errorf("jwt verify failed: %w", err)
 	}
-	user, err := a.Db.Queries.GetUser(ctx, usermap["id"].(string))
+	user, err := a.Db.GetUser(ctx, usermap["id"].(string))
 	if err != nil {
 		return nil, fmt.Errorf("get user failed for token '%s': %w", token, err)
 	}
@@ -0,0 +1,146 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"filippo.io/age"
+	"github.com/robfig/cron/v3"
+	"github.com/tomek7667/secrets/internal/backup"
+)
+
+// backupGracePeriod is how late a scheduled backup may be before the health endpoint reports it
+const backupGracePeriod = time.Hour
+
+type BackupConfig struct {
+	Dir string
+	// Schedule is a cron expression, e.g. "0 3 * * *" or "@daily"
+	Schedule   string
+	Retention  backup.Retention
+	Recipients []age.Recipient
+}
+
+type BackupStatus struct {
+	Enabled       bool         `json:"enabled"`
+	Healthy       bool         `json:"healthy"`
+	Schedule      string       `json:"schedule,omitempty"`
+	LastAttemptAt *time.Time   `json:"last_attempt_at"`
+	LastSuccess   *backup.File `json:"last_success"`
+	LastError     *string      `json:"last_error"`
+	NextRunAt     *time.Time   `json:"next_run_at"`
+}
+
+type backupScheduler struct {
+	config    BackupConfig
+	schedule  cron.Schedule
+	startedAt time.Time
+
+	mu            sync.Mutex
+	lastAttemptAt *time.Time
+	lastSuccess   *backup.File
+	lastError     *string
+}
+
+// EnableBackups makes Serve write backups into the directory on the schedule.
+// The newest backup already in the directory counts as the last successful one.
+func (s *Server) EnableBackups(config BackupConfig) error {
+	schedule, err := cron.ParseStandard(config.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid backup schedule '%s': %w", config.Schedule, err)
+	}
+	if len(config.Recipients) == 0 {
+		return fmt.Errorf("a passphrase or a recipient is required to encrypt backups")
+	}
+	if _, err := s.backupDb(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create the backup directory: %w", err)
+	}
+	files, err := backup.List(config.Dir)
+	if err != nil {
+		return err
+	}
+	scheduler := &backupScheduler{
+		config:    config,
+		schedule:  schedule,
+		startedAt: time.Now().UTC(),
+	}
+	if len(files) > 0 {
+		scheduler.lastSuccess = &files[0]
+	}
+	s.backups = scheduler
+	return nil
+}
+
+func (s *Server) runBackupScheduler() {
+	if s.backups == nil {
+		return
+	}
+	for {
+		time.Sleep(time.Until(s.backups.schedule.Next(time.Now())))
+		s.runBackup(context.Background())
+	}
+}
+
+// runBackup writes a backup and prunes the ones the retention doesn't keep, but only after a successful backup
+func (s *Server) runBackup(ctx context.Context) {
+	b := s.backups
+	now := time.Now().UTC()
+	// EnableBackups has checked the database is sqlite
+	db, _ := s.backupDb()
+	file, err := backup.WriteFile(ctx, db, b.config.Dir, now, b.config.Recipients...)
+
+	b.mu.Lock()
+	b.lastAttemptAt = &now
+	if err != nil {
+		errMsg := err.Error()
+		b.lastError = &errMsg
+	} else {
+		b.lastSuccess = &file
+		b.lastError = nil
+	}
+	b.mu.Unlock()
+
+	if err != nil {
+		s.Log(ErrorEvent, fmt.Sprintf("scheduled backup failed: %s", err.Error()), nil)
+		return
+	}
+	s.Log(BackupEvent, fmt.Sprintf("scheduler wrote backup %s (%d bytes)", file.Name, file.Size), nil)
+	expired, err := backup.Prune(b.config.Dir, b.config.Retention)
+	if err != nil {
+		s.Log(ErrorEvent, fmt.Sprintf("failed to prune backups: %s", err.Error()), nil)
+		return
+	}
+	for _, file := range expired {
+		s.Log(BackupEvent, fmt.Sprintf("scheduler deleted backup %s", file.Name), nil)
+	}
+}
+
+// backupStatus is healthy when the last attempt succeeded and no scheduled backup is overdue
+func (s *Server) backupStatus(now time.Time) BackupStatus {
+	b := s.backups
+	if b == nil {
+		return BackupStatus{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	next := b.schedule.Next(now)
+	since := b.startedAt
+	if b.lastSuccess != nil && b.lastSuccess.CreatedAt.After(since) {
+		since = b.lastSuccess.CreatedAt
+	}
+	overdue := now.After(b.schedule.Next(since).Add(backupGracePeriod))
+	return BackupStatus{
+		Enabled:       true,
+		Healthy:       b.lastError == nil && !overdue,
+		Schedule:      b.config.Schedule,
+		LastAttemptAt: b.lastAttemptAt,
+		LastSuccess:   b.lastSuccess,
+		LastError:     b.lastError,
+		NextRunAt:     &next,
+	}
+}
@@ -0,0 +1,302 @@
+package secrets
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/storage"
+)
+
+// readCache keeps what the api token routes read on every request: tokens, their compiled permissions
+// and the secrets. The writes through the server invalidate it, see cachedStore, the ones of other processes
+// (e.g. another server on the same postgres db) show up after the ttl.
+type readCache struct {
+	db  storage.Store
+	ttl time.Duration
+
+	mu sync.RWMutex
+	// generation changes with every invalidation, a read started before it doesn't save what it read
+	generation  uint64
+	tokens      map[string]cacheEntry[sqlc.Token]
+	permissions map[string]cacheEntry[permissionSet]
+	secrets     map[string]cacheEntry[sqlc.Secret]
+	secretList  map[string]cacheEntry[[]sqlc.Secret]
+	// secretsByPattern is keyed by the LIKE pattern
+	secretsByPattern map[string]cacheEntry[[]sqlc.Secret]
+}
+
+type cacheEntry[T any] struct {
+	value    T
+	cachedAt time.Time
+}
+
+// allSecrets is the key of ListSecrets in secretList
+const allSecrets = ""
+
+func newReadCache(db storage.Store, ttl time.Duration) *readCache {
+	return &readCache{
+		db:               db,
+		ttl:              ttl,
+		tokens:           map[string]cacheEntry[sqlc.Token]{},
+		permissions:      map[string]cacheEntry[permissionSet]{},
+		secrets:          map[string]cacheEntry[sqlc.Secret]{},
+		secretList:       map[string]cacheEntry[[]sqlc.Secret]{},
+		secretsByPattern: map[string]cacheEntry[[]sqlc.Secret]{},
+	}
+}
+
+// invalidate drops everything, the writes are rare compared to the reads
+func (c *readCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.tokens)
+	clear(c.permissions)
+	clear(c.secrets)
+	clear(c.secretList)
+	clear(c.secretsByPattern)
+}
+
+// cached returns the entry of the key, or loads and saves it. Errors aren't cached, so that
+// e.g. a token created after a request with it was rejected works right away.
+func cached[T any](c *readCache, entries map[string]cacheEntry[T], key string, load func() (T, error)) (T, error) {
+	c.mu.RLock()
+	entry, ok := entries[key]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok && time.Since(entry.cachedAt) < c.ttl {
+		return entry.value, nil
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		entries[key] = cacheEntry[T]{value: value, cachedAt: time.Now()}
+	}
+	return value, nil
+}
+
+func (c *readCache) token(ctx context.Context, token string) (sqlc.Token, error) {
+	return cached(c, c.tokens, token, func() (sqlc.Token, error) {
+		return c.db.GetTokenByToken(ctx, token)
+	})
+}
+
+func (c *readCache) permissionSet(ctx context.Context, tokenId string) (permissionSet, error) {
+	return cached(c, c.permissions, tokenId, func() (permissionSet, error) {
+		permissions, err := c.db.ListPermissionsByTokenId(ctx, tokenId)
+		if err != nil {
+			return nil, err
+		}
+		return compilePermissions(permissions), nil
+	})
+}
+
+func (c *readCache) secret(ctx context.Context, key string) (sqlc.Secret, error) {
+	return cached(c, c.secrets, key, func() (sqlc.Secret, error) {
+		return c.db.GetSecret(ctx, key)
+	})
+}
+
+func (c *readCache) listSecrets(ctx context.Context) ([]sqlc.Secret, error) {
+	secrets, err := cached(c, c.secretList, allSecrets, func() ([]sqlc.Secret, error) {
+		return c.db.ListSecrets(ctx)
+	})
+	// the callers may sort or filter it in place
+	return slices.Clone(secrets), err
+}
+
+func (c *readCache) secretsByKeyPattern(ctx context.Context, pattern string) ([]sqlc.Secret, error) {
+	secrets, err := cached(c, c.secretsByPattern, pattern, func() ([]sqlc.Secret, error) {
+		return c.db.ListSecretsByKeyPattern(ctx, pattern)
+	})
+	return slices.Clone(secrets), err
+}
+
+// EnableCache makes the server keep tokens, permissions and secrets in memory for up to ttl.
+// It's called after EnableReplication and EnableCluster, whose changes of the db invalidate it too.
+func (s *Server) EnableCache(ttl time.Duration) {
+	if ttl <= 0 || s.cache != nil {
+		return
+	}
+	s.cache = newReadCache(s.Db, ttl)
+	s.Db = &cachedStore{Store: s.Db, cache: s.cache}
+	if s.cluster != nil {
+		s.cluster.OnChange(s.cache.invalidate)
+	}
+	if s.replication != nil {
+		if _, follower := s.replication.current(); follower != nil {
+			follower.OnChange(s.cache.invalidate)
+		}
+	}
+}
+
+// permissionSet is the compiled permissions of the token
+func (s *Server) permissionSet(ctx context.Context, tokenId string) (permissionSet, error) {
+	if s.cache != nil {
+		return s.cache.permissionSet(ctx, tokenId)
+	}
+	permissions, err := s.Db.ListPermissionsByTokenId(ctx, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	return compilePermissions(permissions), nil
+}
+
+// store is the db under the cache
+func (s *Server) store() storage.Store {
+	if c, ok := s.Db.(*cachedStore); ok {
+		return c.Store
+	}
+	return s.Db
+}
+
+// cachedStore serves the cached reads and invalidates the cache after the writes of tokens,
+// permissions and secrets, including the ones in transactions
+type cachedStore struct {
+	storage.Store
+	cache *readCache
+}
+
+func (c *cachedStore) GetTokenByToken(ctx context.Context, token string) (sqlc.Token, error) {
+	return c.cache.token(ctx, token)
+}
+
+func (c *cachedStore) GetSecret(ctx context.Context, key string) (sqlc.Secret, error) {
+	return c.cache.secret(ctx, key)
+}
+
+func (c *cachedStore) ListSecrets(ctx context.Context) ([]sqlc.Secret, error) {
+	return c.cache.listSecrets(ctx)
+}
+
+func (c *cachedStore) ListSecretsByKeyPattern(ctx context.Context, pattern string) ([]sqlc.Secret, error) {
+	return c.cache.secretsByKeyPattern(ctx, pattern)
+}
+
+func (c *cachedStore) WithTx(ctx context.Context, fn func(q sqlc.Querier) error) error {
+	q := &invalidatingQuerier{}
+	defer func() {
+		if q.changed {
+			c.cache.invalidate()
+		}
+	}()
+	return c.Store.WithTx(ctx, func(tx sqlc.Querier) error {
+		q.Querier = tx
+		return fn(q)
+	})
+}
+
+func (c *cachedStore) CreateSecret(ctx context.Context, arg sqlc.CreateSecretParams) (sqlc.Secret, error) {
+	defer c.cache.invalidate()
+	return c.Store.CreateSecret(ctx, arg)
+}
+
+func (c *cachedStore) UpdateSecret(ctx context.Context, arg sqlc.UpdateSecretParams) (sqlc.Secret, error) {
+	defer c.cache.invalidate()
+	return c.Store.UpdateSecret(ctx, arg)
+}
+
+func (c *cachedStore) UpdateSecretDates(ctx context.Context, arg sqlc.UpdateSecretDatesParams) (sqlc.Secret, error) {
+	defer c.cache.invalidate()
+	return c.Store.UpdateSecretDates(ctx, arg)
+}
+
+func (c *cachedStore) DeleteSecret(ctx context.Context, key string) error {
+	defer c.cache.invalidate()
+	return c.Store.DeleteSecret(ctx, key)
+}
+
+func (c *cachedStore) CreateToken(ctx context.Context, arg sqlc.CreateTokenParams) (sqlc.Token, error) {
+	defer c.cache.invalidate()
+	return c.Store.CreateToken(ctx, arg)
+}
+
+func (c *cachedStore) UpdateToken(ctx context.Context, arg sqlc.UpdateTokenParams) (sqlc.Token, error) {
+	defer c.cache.invalidate()
+	return c.Store.UpdateToken(ctx, arg)
+}
+
+// DeleteToken deletes the permissions of the token too, on cascade
+func (c *cachedStore) DeleteToken(ctx context.Context, id string) error {
+	defer c.cache.invalidate()
+	return c.Store.DeleteToken(ctx, id)
+}
+
+func (c *cachedStore) CreatePermission(ctx context.Context, arg sqlc.CreatePermissionParams) (sqlc.Permission, error) {
+	defer c.cache.invalidate()
+	return c.Store.CreatePermission(ctx, arg)
+}
+
+func (c *cachedStore) UpdatePermission(ctx context.Context, arg sqlc.UpdatePermissionParams) (sqlc.Permission, error) {
+	defer c.cache.invalidate()
+	return c.Store.UpdatePermission(ctx, arg)
+}
+
+func (c *cachedStore) DeletePermission(ctx context.Context, id string) error {
+	defer c.cache.invalidate()
+	return c.Store.DeletePermission(ctx, id)
+}
+
+// invalidatingQuerier notes the writes of a transaction, the cache is invalidated once it's over.
+// The reads go to the transaction, which may see its own writes.
+type invalidatingQuerier struct {
+	sqlc.Querier
+	changed bool
+}
+
+func (q *invalidatingQuerier) CreateSecret(ctx context.Context, arg sqlc.CreateSecretParams) (sqlc.Secret, error) {
+	q.changed = true
+	return q.Querier.CreateSecret(ctx, arg)
+}
+
+func (q *invalidatingQuerier) UpdateSecret(ctx context.Context, arg sqlc.UpdateSecretParams) (sqlc.Secret, error) {
+	q.changed = true
+	return q.Querier.UpdateSecret(ctx, arg)
+}
+
+func (q *invalidatingQuerier) UpdateSecretDates(ctx context.Context, arg sqlc.UpdateSecretDatesParams) (sqlc.Secret, error) {
+	q.changed = true
+	return q.Querier.UpdateSecretDates(ctx, arg)
+}
+
+func (q *invalidatingQuerier) DeleteSecret(ctx context.Context, key string) error {
+	q.changed = true
+	return q.Querier.DeleteSecret(ctx, key)
+}
+
+func (q *invalidatingQuerier) CreateToken(ctx context.Context, arg sqlc.CreateTokenParams) (sqlc.Token, error) {
+	q.changed = true
+	return q.Querier.CreateToken(ctx, arg)
+}
+
+func (q *invalidatingQuerier) UpdateToken(ctx context.Context, arg sqlc.UpdateTokenParams) (sqlc.Token, error) {
+	q.changed = true
+	return q.Querier.UpdateToken(ctx, arg)
+}
+
+func (q *invalidatingQuerier) DeleteToken(ctx context.Context, id string) error {
+	q.changed = true
+	return q.Querier.DeleteToken(ctx, id)
+}
+
+func (q *invalidatingQuerier) CreatePermission(ctx context.Context, arg sqlc.CreatePermissionParams) (sqlc.Permission, error) {
+	q.changed = true
+	return q.Querier.CreatePermission(ctx, arg)
+}
+
+func (q *invalidatingQuerier) UpdatePermission(ctx context.Context, arg sqlc.UpdatePermissionParams) (sqlc.Permission, error) {
+	q.changed = true
+	return q.Querier.UpdatePermission(ctx, arg)
+}
+
+func (q *invalidatingQuerier) DeletePermission(ctx context.Context, id string) error {
+	q.changed = true
+	return q.Querier.DeletePermission(ctx, id)
+}
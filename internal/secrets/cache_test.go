@@ -0,0 +1,68 @@
+package secrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	srv.EnableCache(time.Hour)
+	srv.SetupRoutes()
+	get := func() (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/secrets/get?key=app/db", nil)
+		req.Header.Set("Authorization", "Api token")
+		rec := httptest.NewRecorder()
+		srv.Router.ServeHTTP(rec, req)
+		var res struct{ Data sqlc.Secret }
+		json.NewDecoder(rec.Body).Decode(&res)
+		return rec.Code, res.Data.Value
+	}
+
+	if _, err := srv.Db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s1", Key: "app/db", Value: "djE="}); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := get(); code != http.StatusUnauthorized {
+		t.Fatalf("expected an unknown token to be rejected, got %d", code)
+	}
+	if _, err := srv.Db.CreateToken(ctx, sqlc.CreateTokenParams{ID: "t1", Token: "token"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: "p1", TokenID: "t1", SecretKeyPattern: "app/*"}); err != nil {
+		t.Fatal(err)
+	}
+	if code, value := get(); code != http.StatusOK || value != "djE=" {
+		t.Fatalf("expected the secret, got %d %q", code, value)
+	}
+
+	err := srv.Db.WithTx(ctx, func(q sqlc.Querier) error {
+		_, err := q.UpdateSecret(ctx, sqlc.UpdateSecretParams{Key: "app/db", Value: "djI="})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, value := get(); code != http.StatusOK || value != "djI=" {
+		t.Fatalf("expected the updated secret, got %d %q", code, value)
+	}
+
+	if err := srv.Db.DeletePermission(ctx, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := get(); code != http.StatusUnauthorized {
+		t.Fatalf("expected the token without the permission to be rejected, got %d", code)
+	}
+	if err := srv.Db.DeleteToken(ctx, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := get(); code != http.StatusUnauthorized {
+		t.Fatalf("expected the deleted token to be rejected, got %d", code)
+	}
+}
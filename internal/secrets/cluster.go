@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/tomek7667/secrets/internal/cluster"
+	"github.com/tomek7667/secrets/internal/sqlite"
+	"github.com/tomek7667/secrets/internal/tracing"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// EnableCluster makes the server a node of the cluster, the db must be the node, see cluster.Open
+func (s *Server) EnableCluster(node *cluster.Node) {
+	s.cluster = node
+}
+
+// leading tells whether the background jobs run now, a cluster node runs them while it's the leader
+func (s *Server) leading() bool {
+	return s.cluster == nil || s.cluster.Leader()
+}
+
+// sqliteDb is the local sqlite db, of the cluster node too
+func (s *Server) sqliteDb() (*sqlite.Client, bool) {
+	if s.cluster != nil {
+		return s.cluster.Sqlite(), true
+	}
+	c, ok := s.store().(*sqlite.Client)
+	return c, ok
+}
+
+// forwardToLeader proxies the requests a node doesn't serve from its db to the leader, see followerRoutes
+func (s *Server) forwardToLeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a lease is written to the db
+		local := followerRoutes[r.Method+" "+r.URL.Path] && !r.URL.Query().Has("lease")
+		if local || s.cluster.Leader() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		leader, err := url.Parse(s.cluster.LeaderUrl())
+		if err != nil || leader.Host == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]any{"success": false, "message": cluster.ErrNoLeader.Error()})
+			return
+		}
+		proxy := &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.SetURL(leader)
+				pr.SetXForwarded()
+				// the leader's spans continue the trace of this node's
+				tracing.Propagator.Inject(pr.In.Context(), propagation.HeaderCarrier(pr.Out.Header))
+			},
+			// /api/secrets/watch streams
+			FlushInterval: -1,
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,197 @@
+package secrets_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/audit"
+	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+// datedSecrets creates the secrets with the dates set through PUT /api/secrets/dates, the dates are relative to now
+func datedSecrets(t *testing.T, srv *secrets.Server, db *sqlite.Client, dates map[string][2]time.Duration) {
+	t.Helper()
+	jwt := "Bearer " + adminJwt(t, db)
+	now := time.Now().UTC()
+	date := func(d time.Duration) string {
+		if d == 0 {
+			return "null"
+		}
+		return fmt.Sprintf("%q", now.Add(d).Format(time.RFC3339))
+	}
+	for key, d := range dates {
+		if _, err := db.CreateSecret(context.Background(), sqlc.CreateSecretParams{ID: key, Key: key, Value: "djE="}); err != nil {
+			t.Fatal(err)
+		}
+		body := fmt.Sprintf(`{"expires_at":%s,"rotate_by":%s}`, date(d[0]), date(d[1]))
+		if code := serve(srv, http.MethodPut, "/api/secrets/dates?key="+key, jwt, body, nil); code != http.StatusOK {
+			t.Fatalf("expected the dates of %s to be set, got %d", key, code)
+		}
+	}
+}
+
+const day = 24 * time.Hour
+
+func TestSecretWarnings(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	datedSecrets(t, srv, db, map[string][2]time.Duration{
+		"app/fresh":    {30 * day, 0},
+		"app/expiring": {2 * day, 2 * day},
+		"app/expired":  {-day, 0},
+		"app/stale":    {0, -2 * day},
+		"app/both":     {-day, -2 * day},
+	})
+	if _, err := db.CreateToken(ctx, sqlc.CreateTokenParams{ID: "t1", Token: "token"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: "p1", TokenID: "t1", SecretKeyPattern: "app/*"}); err != nil {
+		t.Fatal(err)
+	}
+	warnings := func(key string) []string {
+		req := httptest.NewRequest(http.MethodGet, "/api/secrets/get?key="+key, nil)
+		req.Header.Set("Authorization", "Api token")
+		rec := httptest.NewRecorder()
+		srv.Router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected %s to be returned, got %d", key, rec.Code)
+		}
+		return rec.Header().Values("Warning")
+	}
+
+	for _, key := range []string{"app/fresh", "app/expiring"} {
+		if w := warnings(key); len(w) != 0 {
+			t.Errorf("expected no warning for %s before its dates, got %q", key, w)
+		}
+	}
+	tests := map[string][]string{
+		"app/expired": {"secret expired at"},
+		"app/stale":   {"secret is past its rotate-by date"},
+		"app/both":    {"secret expired at", "secret is past its rotate-by date"},
+	}
+	for key, expected := range tests {
+		w := warnings(key)
+		if len(w) != len(expected) {
+			t.Errorf("expected %d warnings for %s, got %q", len(expected), key, w)
+			continue
+		}
+		for i := range expected {
+			if !strings.HasPrefix(w[i], `299 - "`+expected[i]) {
+				t.Errorf("expected warning %q of %s to start with %q", w[i], key, expected[i])
+			}
+		}
+	}
+}
+
+func TestListExpiringSecrets(t *testing.T) {
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	datedSecrets(t, srv, db, map[string][2]time.Duration{
+		"app/undated":  {0, 0},
+		"app/far":      {30 * day, 60 * day},
+		"app/expiring": {10 * day, 0},
+		"app/rotate":   {30 * day, 2 * day},
+		"app/expired":  {-day, 20 * day},
+		"app/stale":    {5 * day, -3 * day},
+	})
+	jwt := "Bearer " + adminJwt(t, db)
+	list := func(within string) []string {
+		var secrets []sqlc.Secret
+		if code := serve(srv, http.MethodGet, "/api/secrets/expiring"+within, jwt, "", &secrets); code != http.StatusOK {
+			t.Fatalf("expected the expiring secrets, got %d", code)
+		}
+		keys := make([]string, len(secrets))
+		for i, secret := range secrets {
+			keys[i] = secret.Key
+		}
+		return keys
+	}
+
+	// ordered by the earlier of the two dates, the overdue ones first
+	expected := []string{"app/stale", "app/expired", "app/rotate", "app/expiring"}
+	if keys := list(""); fmt.Sprint(keys) != fmt.Sprint(expected) {
+		t.Errorf("expected %v within two weeks, got %v", expected, keys)
+	}
+	expected = []string{"app/stale", "app/expired", "app/rotate"}
+	if keys := list("?within=72h"); fmt.Sprint(keys) != fmt.Sprint(expected) {
+		t.Errorf("expected %v within 72h, got %v", expected, keys)
+	}
+	if code := serve(srv, http.MethodGet, "/api/secrets/expiring?within=soon", jwt, "", nil); code != http.StatusBadRequest {
+		t.Errorf("expected an invalid duration to be rejected, got %d", code)
+	}
+}
+
+type chanSink chan audit.Entry
+
+func (c chanSink) Name() string { return "chan" }
+
+func (c chanSink) Write(e audit.Entry) error {
+	c <- e
+	return nil
+}
+
+func (c chanSink) Close() error { return nil }
+
+func TestExpiryChecker(t *testing.T) {
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	datedSecrets(t, srv, db, map[string][2]time.Duration{
+		"app/far":      {30 * day, 0},
+		"app/expiring": {3 * day, 0},
+		"app/stale":    {0, -day},
+		"app/both":     {day, 2 * day},
+	})
+	entries := make(chanSink, 16)
+	srv.AddAuditSink(entries)
+
+	srv.CheckExpiringSecrets()
+	expected := map[string]bool{
+		"secret app/expiring expires at":        false,
+		"secret app/stale should be rotated by": false,
+		"secret app/both expires at":            false,
+		"secret app/both should be rotated by":  false,
+	}
+	// the sink also gets the events of setting the dates, which are written after the transactions
+	expiring := func(wait time.Duration) (audit.Entry, bool) {
+		timeout := time.After(wait)
+		for {
+			select {
+			case e := <-entries:
+				if e.Event == secrets.ExpiringEvent.String() {
+					return e, true
+				}
+			case <-timeout:
+				return audit.Entry{}, false
+			}
+		}
+	}
+	for range expected {
+		e, ok := expiring(5 * time.Second)
+		if !ok {
+			t.Fatalf("expected %d expiring events, got %v", len(expected), expected)
+		}
+		if e.Severity != audit.SeverityWarning {
+			t.Errorf("expected the expiring event to be a warning, got severity %d", e.Severity)
+		}
+		matched := false
+		for prefix := range expected {
+			if strings.HasPrefix(e.Msg, prefix) {
+				expected[prefix], matched = true, true
+			}
+		}
+		if !matched {
+			t.Errorf("unexpected expiring event %q", e.Msg)
+		}
+	}
+	if e, ok := expiring(100 * time.Millisecond); ok {
+		t.Errorf("unexpected expiring event %q", e.Msg)
+	}
+}
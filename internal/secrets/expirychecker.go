@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const expiryCheckInterval = 24 * time.Hour
+
+// runExpiryChecker emits an expiring event once a day for every secret that expires or
+// should be rotated within the next two weeks, so that audit sinks can alert on them.
+func (s *Server) runExpiryChecker() {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+	for {
+		if s.leading() {
+			s.checkExpiringSecrets(context.Background())
+		}
+		<-ticker.C
+	}
+}
+
+func (s *Server) checkExpiringSecrets(ctx context.Context) {
+	now := time.Now().UTC()
+	before := now.Add(defaultExpiringWithin)
+	secrets, err := s.Db.ListExpiringSecrets(ctx, &before)
+	if err != nil {
+		slog.Error("failed to list expiring secrets", "err", err)
+		return
+	}
+	for _, secret := range secrets {
+		if secret.ExpiresAt != nil && secret.ExpiresAt.Before(before) {
+			s.Log(ExpiringEvent, fmt.Sprintf("secret %s expires at %s (%s)", secret.Key, secret.ExpiresAt.Format(time.RFC3339), relativeTo(*secret.ExpiresAt, now)), nil)
+		}
+		if secret.RotateBy != nil && secret.RotateBy.Before(before) {
+			s.Log(ExpiringEvent, fmt.Sprintf("secret %s should be rotated by %s (%s)", secret.Key, secret.RotateBy.Format(time.RFC3339), relativeTo(*secret.RotateBy, now)), nil)
+		}
+	}
+}
+
+func relativeTo(t, now time.Time) string {
+	d := t.Sub(now).Round(time.Hour)
+	if d < 0 {
+		return fmt.Sprintf("%s ago", -d)
+	}
+	return fmt.Sprintf("in %s", d)
+}
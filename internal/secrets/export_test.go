@@ -0,0 +1,43 @@
+package secrets
+
+import "context"
+
+// the unexported parts the secrets_test tests need
+
+var (
+	WebhookSignature  = webhookSignature
+	WebhookBackoff    = webhookBackoff
+	WebhookSubscribed = webhookSubscribed
+)
+
+const WebhookMaxAttempts = webhookMaxAttempts
+
+func (s *Server) DeliverDueWebhooks() {
+	s.deliverDueWebhooks(context.Background())
+}
+
+var NewWatchHub = newWatchHub
+
+const WatchSubscriberBuffer = watchSubscriberBuffer
+
+func (wh *watchHub) Subscribe() chan SecretChange {
+	return wh.subscribe()
+}
+
+func (wh *watchHub) Publish(change SecretChange) {
+	wh.publish(change)
+}
+
+func (s *Server) PublishSecretChange(change SecretChange) {
+	s.watchHub.publish(change)
+}
+
+var SealShare = sealShare
+
+func (s *Server) WithTx(ctx context.Context, fn func(tx *Tx) error) error {
+	return s.withTx(ctx, nil, fn)
+}
+
+func (s *Server) CheckExpiringSecrets() {
+	s.checkExpiringSecrets(context.Background())
+}
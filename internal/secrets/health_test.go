@@ -0,0 +1,61 @@
+package secrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/buildinfo"
+	"github.com/tomek7667/secrets/secretssdk"
+)
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	server := httptest.NewServer(srv.Router)
+	defer server.Close()
+
+	// the sdk pings the server and compares the versions
+	client, err := secretssdk.New(server.URL, "token")
+	if err != nil {
+		t.Fatalf("expected the sdk to reach the server, got %v", err)
+	}
+	version, err := client.ServerVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != buildinfo.Read().Version {
+		t.Errorf("expected the server version %s, got %s", buildinfo.Read().Version, version)
+	}
+
+	get := func(path string) (int, map[string]string) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, body.Data
+	}
+	if code, _ := get("/healthz"); code != http.StatusOK {
+		t.Errorf("expected /healthz to be ok, got %d", code)
+	}
+	if code, checks := get("/readyz"); code != http.StatusOK || checks["migrations"] != "ok" {
+		t.Errorf("expected /readyz to be ok, got %d %v", code, checks)
+	}
+
+	if _, err := db.MigrateDown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if code, checks := get("/readyz"); code != http.StatusServiceUnavailable || checks["db"] != "ok" || checks["migrations"] == "ok" {
+		t.Errorf("expected /readyz to report the pending migration, got %d %v", code, checks)
+	}
+}
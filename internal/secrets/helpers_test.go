@@ -0,0 +1,56 @@
+package secrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+// newTestServer serves a new sqlite db, which is closed after the test. The routes aren't set up,
+// so that the test can enable e.g. the cache before.
+func newTestServer(t testing.TB) (*secrets.Server, *sqlite.Client) {
+	t.Helper()
+	db, err := sqlite.New(context.Background(), filepath.Join(t.TempDir(), "secrets.sqlite"), sqlite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	srv, err := secrets.New("", "", db, "jwt", "pw", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, db
+}
+
+// adminJwt signs in the admin user newTestServer creates, for the "Authorization: Bearer <jwt>" header
+func adminJwt(t testing.TB, db *sqlite.Client) string {
+	t.Helper()
+	users, err := db.ListUsers(context.Background())
+	if err != nil || len(users) == 0 {
+		t.Fatalf("expected the admin user, got %v", err)
+	}
+	jwt, err := secrets.Auther{Db: db, JwtSecret: "jwt"}.GetToken(&users[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwt
+}
+
+// serve sends the request to the router with the authorization, decodes the data of the response into data and returns the status code
+func serve(srv *secrets.Server, method, url, authorization, body string, data any) int {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, req)
+	if data != nil {
+		json.NewDecoder(rec.Body).Decode(&struct{ Data any }{Data: data})
+	}
+	return rec.Code
+}
@@ -0,0 +1,153 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/dynamic"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+const (
+	leaseReapInterval = time.Minute
+	maxSecretLeaseTtl = 24 * time.Hour
+)
+
+// IssuedLease is the only time the lease password is returned, it's never saved
+type IssuedLease struct {
+	sqlc.Lease
+	Password string `json:"password"`
+}
+
+// runLeaseReaper revokes expired leases. Leases that fail to revoke are retried on the next tick.
+func (s *Server) runLeaseReaper() {
+	ticker := time.NewTicker(leaseReapInterval)
+	defer ticker.Stop()
+	for {
+		if s.leading() {
+			s.revokeExpiredLeases(context.Background())
+		}
+		<-ticker.C
+	}
+}
+
+func (s *Server) revokeExpiredLeases(ctx context.Context) {
+	leases, err := s.Db.ListExpiredLeases(ctx, time.Now().UTC())
+	if err != nil {
+		slog.Error("failed to list expired leases", "err", err)
+		return
+	}
+	for _, lease := range leases {
+		_, err := s.revokeLease(ctx, lease)
+		if err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("failed to revoke expired lease %s of %s: %s", lease.ID, leaseSubject(lease), err.Error()), nil)
+		} else {
+			s.Log(RevokeLeaseEvent, fmt.Sprintf("reaper revoked expired lease %s of %s", lease.ID, leaseSubject(lease)), nil)
+		}
+	}
+}
+
+// issueLease creates new credentials for the role valid for ttl, capped at the role maximum.
+// A zero ttl uses the role default.
+func (s *Server) issueLease(ctx context.Context, role sqlc.DynamicRole, tokenID *string, ttl time.Duration) (IssuedLease, error) {
+	if ttl <= 0 {
+		ttl = time.Duration(role.DefaultTtlSeconds) * time.Second
+	}
+	ttl = min(ttl, time.Duration(role.MaxTtlSeconds)*time.Second)
+	engine, err := dynamic.New(dynamic.Kind(role.Engine), role.Config)
+	if err != nil {
+		return IssuedLease{}, err
+	}
+	creds, err := dynamic.NewCredentials(role.Name)
+	if err != nil {
+		return IssuedLease{}, err
+	}
+	expiresAt := time.Now().UTC().Add(ttl)
+	// the lease is saved first, so that the reaper cleans up credentials even if saving would fail afterwards
+	lease, err := s.Db.CreateLease(ctx, sqlc.CreateLeaseParams{
+		ID:        utils.CreateUUID(),
+		RoleID:    &role.ID,
+		TokenID:   tokenID,
+		Username:  &creds.Username,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return IssuedLease{}, fmt.Errorf("failed to save the lease: %w", err)
+	}
+	if err := engine.Create(ctx, creds, expiresAt); err != nil {
+		now := time.Now().UTC()
+		s.Db.RevokeLease(ctx, sqlc.RevokeLeaseParams{ID: lease.ID, RevokedAt: &now})
+		return IssuedLease{}, fmt.Errorf("failed to create credentials: %w", err)
+	}
+	return IssuedLease{Lease: lease, Password: creds.Password}, nil
+}
+
+// issueSecretLease records that the token read the secret and is expected to hold it for ttl, capped at a day
+func (s *Server) issueSecretLease(ctx context.Context, key string, tokenID string, ttl time.Duration) (sqlc.Lease, error) {
+	return s.Db.CreateLease(ctx, sqlc.CreateLeaseParams{
+		ID:        utils.CreateUUID(),
+		SecretKey: &key,
+		TokenID:   &tokenID,
+		ExpiresAt: time.Now().UTC().Add(min(ttl, maxSecretLeaseTtl)),
+	})
+}
+
+// revokeLease invalidates the lease, removing dynamic credentials from the target first.
+// The error is saved on the lease when it fails.
+func (s *Server) revokeLease(ctx context.Context, lease sqlc.Lease) (sqlc.Lease, error) {
+	var err error
+	if lease.RoleID != nil && lease.Username != nil {
+		err = s.revokeDynamicCredentials(ctx, *lease.RoleID, *lease.Username)
+	}
+	if err != nil {
+		errMsg := err.Error()
+		if err := s.Db.UpdateLeaseError(ctx, sqlc.UpdateLeaseErrorParams{ID: lease.ID, LastError: &errMsg}); err != nil {
+			slog.Error("failed to save the lease error", "err", err, "lease", lease.ID)
+		}
+		return lease, err
+	}
+	now := time.Now().UTC()
+	return s.Db.RevokeLease(ctx, sqlc.RevokeLeaseParams{ID: lease.ID, RevokedAt: &now})
+}
+
+func (s *Server) revokeDynamicCredentials(ctx context.Context, roleID, username string) error {
+	role, err := s.Db.GetDynamicRole(ctx, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to get role %s: %w", roleID, err)
+	}
+	engine, err := dynamic.New(dynamic.Kind(role.Engine), role.Config)
+	if err != nil {
+		return err
+	}
+	return engine.Revoke(ctx, username)
+}
+
+// revokeLeases revokes all the leases, returning how many were revoked and the first error
+func (s *Server) revokeLeases(ctx context.Context, leases []sqlc.Lease) (int, error) {
+	revoked := 0
+	var firstErr error
+	for _, lease := range leases {
+		if _, err := s.revokeLease(ctx, lease); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to revoke lease %s: %w", lease.ID, err)
+			}
+			continue
+		}
+		revoked++
+	}
+	return revoked, firstErr
+}
+
+// leaseSubject names what the lease was issued for, for logs
+func leaseSubject(lease sqlc.Lease) string {
+	if lease.Username != nil {
+		return *lease.Username
+	}
+	if lease.SecretKey != nil {
+		return "secret " + *lease.SecretKey
+	}
+	return "unknown"
+}
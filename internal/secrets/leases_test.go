@@ -0,0 +1,52 @@
+package secrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestRenewLeaseNeedsPermission(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	do := func(method, url string) (int, secrets.LeasedSecret) {
+		req := httptest.NewRequest(method, url, nil)
+		req.Header.Set("Authorization", "Api token")
+		rec := httptest.NewRecorder()
+		srv.Router.ServeHTTP(rec, req)
+		var res struct{ Data secrets.LeasedSecret }
+		json.NewDecoder(rec.Body).Decode(&res)
+		return rec.Code, res.Data
+	}
+
+	if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s1", Key: "app/db", Value: "djE="}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateToken(ctx, sqlc.CreateTokenParams{ID: "t1", Token: "token"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: "p1", TokenID: "t1", SecretKeyPattern: "app/*"}); err != nil {
+		t.Fatal(err)
+	}
+	code, leased := do(http.MethodGet, "/api/secrets/get?key=app/db&lease=1m")
+	if code != http.StatusOK || leased.LeaseID == nil {
+		t.Fatalf("expected a leased secret, got %d", code)
+	}
+	renew := "/api/leases/" + *leased.LeaseID + "/renew?ttl=1h"
+	if code, _ := do(http.MethodPost, renew); code != http.StatusOK {
+		t.Fatalf("expected the lease to be renewed, got %d", code)
+	}
+
+	if err := db.DeletePermission(ctx, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := do(http.MethodPost, renew); code != http.StatusUnauthorized {
+		t.Errorf("expected the renewal without the permission to be rejected, got %d", code)
+	}
+}
@@ -4,57 +4,169 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/audit"
+	"github.com/tomek7667/secrets/internal/metrics"
 	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type LogEvent string
 
 const (
-	ErrorEvent            LogEvent = "error"
-	UnauthorizedEvent     LogEvent = "unauthorized"
-	IngestEvent           LogEvent = "ingest"
-	DeleteEvent           LogEvent = "delete"
-	GetSecretEvent        LogEvent = "get-secret"
-	GetFullEnvEvent       LogEvent = "get-full-env"
-	UpdateSecretEvent     LogEvent = "update-secret"
-	UpdateTokenEvent      LogEvent = "update-token"
-	GetUsersEvent         LogEvent = "get-users"
-	GetSecretsEvent       LogEvent = "get-secrets"
-	GetTokensEvent        LogEvent = "get-tokens"
-	GetPermissionsEvent   LogEvent = "get-permissions"
-	UpdatePermissionEvent LogEvent = "update-permission"
-	LoginSuccessEvent     LogEvent = "login-success"
-	LoginFailedEvent      LogEvent = "login-failed"
+	ErrorEvent             LogEvent = "error"
+	UnauthorizedEvent      LogEvent = "unauthorized"
+	IngestEvent            LogEvent = "ingest"
+	DeleteEvent            LogEvent = "delete"
+	GetSecretEvent         LogEvent = "get-secret"
+	GetFullEnvEvent        LogEvent = "get-full-env"
+	UpdateSecretEvent      LogEvent = "update-secret"
+	UpdateTokenEvent       LogEvent = "update-token"
+	GetUsersEvent          LogEvent = "get-users"
+	GetSecretsEvent        LogEvent = "get-secrets"
+	GetTokensEvent         LogEvent = "get-tokens"
+	GetPermissionsEvent    LogEvent = "get-permissions"
+	UpdatePermissionEvent  LogEvent = "update-permission"
+	LoginSuccessEvent      LogEvent = "login-success"
+	LoginFailedEvent       LogEvent = "login-failed"
+	UpdateWebhookEvent     LogEvent = "update-webhook"
+	GetWebhooksEvent       LogEvent = "get-webhooks"
+	WebhookFailedEvent     LogEvent = "webhook-failed"
+	WatchSecretsEvent      LogEvent = "watch-secrets"
+	GetRotationsEvent      LogEvent = "get-rotations"
+	UpdateRotationEvent    LogEvent = "update-rotation"
+	RotateSecretEvent      LogEvent = "rotate-secret"
+	ExpiringEvent          LogEvent = "expiring"
+	GetDynamicRolesEvent   LogEvent = "get-dynamic-roles"
+	UpdateDynamicRoleEvent LogEvent = "update-dynamic-role"
+	GetLeasesEvent         LogEvent = "get-leases"
+	IssueLeaseEvent        LogEvent = "issue-lease"
+	RevokeLeaseEvent       LogEvent = "revoke-lease"
+	RenewLeaseEvent        LogEvent = "renew-lease"
+	GetSharesEvent         LogEvent = "get-shares"
+	CreateShareEvent       LogEvent = "create-share"
+	RevealShareEvent       LogEvent = "reveal-share"
+	ExportSecretsEvent     LogEvent = "export-secrets"
+	BackupEvent            LogEvent = "backup"
+	ConsistencyCheckEvent  LogEvent = "consistency-check"
+	PromoteEvent           LogEvent = "promote"
+	ClusterEvent           LogEvent = "cluster"
 )
 
 func (le LogEvent) String() string {
 	return string(le)
 }
 
+func (le LogEvent) Severity() audit.Severity {
+	switch le {
+	case ErrorEvent, WebhookFailedEvent:
+		return audit.SeverityError
+	case UnauthorizedEvent, LoginFailedEvent, ExpiringEvent:
+		return audit.SeverityWarning
+	default:
+		return audit.SeverityInfo
+	}
+}
+
+// AddAuditSink registers a sink that receives every log entry next to the sqlite log table.
+func (s *Server) AddAuditSink(sink audit.Sink) {
+	s.auditSinks = append(s.auditSinks, sink)
+}
+
+// Log saves the event asynchronously. r is nil for events raised by background jobs.
 func (s *Server) Log(event LogEvent, msg string, r *http.Request) {
+	// the spans of the write belong to the trace of the request, which may be over before they start
+	ctx := context.Background()
+	if r != nil {
+		ctx = context.WithoutCancel(r.Context())
+	}
+	metrics.AuditQueue.Inc()
 	go func() {
-		requestedUrl := r.Method + " " + r.URL.String()
+		defer metrics.AuditQueue.Dec()
+		ctx, span := tracing.Tracer().Start(ctx, "audit.write", trace.WithAttributes(attribute.String("audit.event", event.String())))
+		defer span.End()
 		slog.Debug(
 			msg,
 			"event", event,
 		)
 
-		_, err := s.Db.Queries.CreateLog(context.Background(), sqlc.CreateLogParams{
-			ID:           utils.CreateUUID(),
-			Event:        event.String(),
-			Msg:          msg,
-			RequestedUrl: &requestedUrl,
-			RemoteAddr:   &r.RemoteAddr,
-		})
-		if err != nil {
+		params := logParams(event, msg, r)
+		// sinks still get the entry so the siem doesn't miss events when sqlite fails,
+		// and followers only have the sinks, their replica is read only
+		entry := sqlc.Log{
+			ID:           params.ID,
+			Event:        params.Event,
+			Msg:          params.Msg,
+			RequestedUrl: params.RequestedUrl,
+			RemoteAddr:   params.RemoteAddr,
+		}
+		if !s.following() {
+			saved, err := s.Db.CreateLog(ctx, params)
+			if err != nil {
+				span.RecordError(err)
+				slog.Error(
+					"failed to save a log entry",
+					"err", err,
+					"event", event,
+					"msg", msg,
+				)
+			} else {
+				entry = saved
+			}
+		}
+		s.writeToAuditSinks(ctx, event, entry)
+	}()
+}
+
+func logParams(event LogEvent, msg string, r *http.Request) sqlc.CreateLogParams {
+	params := sqlc.CreateLogParams{
+		ID:    utils.CreateUUID(),
+		Event: event.String(),
+		Msg:   msg,
+	}
+	if r != nil {
+		requestedUrl := r.Method + " " + r.URL.String()
+		params.RequestedUrl = &requestedUrl
+		params.RemoteAddr = &r.RemoteAddr
+	}
+	return params
+}
+
+func (s *Server) writeToAuditSinks(ctx context.Context, event LogEvent, entry sqlc.Log) {
+	if len(s.auditSinks) == 0 {
+		return
+	}
+	_, span := tracing.Tracer().Start(ctx, "audit.sinks", trace.WithAttributes(attribute.String("audit.event", event.String())))
+	defer span.End()
+	e := audit.Entry{
+		ID:       entry.ID,
+		Time:     time.Now(),
+		Event:    entry.Event,
+		Severity: event.Severity(),
+		Msg:      entry.Msg,
+	}
+	if entry.CreatedAt != nil {
+		e.Time = *entry.CreatedAt
+	}
+	if entry.RequestedUrl != nil {
+		e.RequestedUrl = *entry.RequestedUrl
+	}
+	if entry.RemoteAddr != nil {
+		e.RemoteAddr = *entry.RemoteAddr
+	}
+	for _, sink := range s.auditSinks {
+		if err := sink.Write(e); err != nil {
+			span.RecordError(err)
 			slog.Error(
-				"failed to save a log entry",
+				"failed to write a log entry to audit sink",
 				"err", err,
+				"sink", sink.Name(),
 				"event", event,
-				"msg", msg,
 			)
 		}
-	}()
+	}
 }
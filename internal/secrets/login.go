@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/tomek7667/go-http-helpers/h"
+	"github.com/tomek7667/secrets/internal/metrics"
 )
 
 type GetLoginDto struct {
@@ -23,27 +24,31 @@ func (s *Server) PostLogin() {
 		}
 		err = s.verifyCaptcha(r.Context(), dto.CaptchaToken)
 		if err != nil {
+			metrics.Logins.WithLabelValues("failure").Inc()
 			h.ResBadRequest(w, err)
 			return
 		}
 
-		user, err := s.Db.Queries.GetUserByUsername(r.Context(), dto.Username)
+		user, err := s.Db.GetUserByUsername(r.Context(), dto.Username)
 		if err != nil || user.Password != dto.Password {
 			slog.Warn(
 				"s.Dber.GetUserByUsername(r.Context(), dto.Username)",
 				"err", err,
 				"given pass", dto.Password,
 			)
+			metrics.Logins.WithLabelValues("failure").Inc()
 			s.Log(LoginFailedEvent, fmt.Sprintf("GetUserByUsername for %s with password %s", dto.Username, dto.Password), r)
 			h.ResErr(w, fmt.Errorf("invalid username or password"))
 			return
 		}
 		token, err := s.auther.GetToken(&user)
 		if err != nil {
+			metrics.Logins.WithLabelValues("failure").Inc()
 			s.Log(LoginFailedEvent, fmt.Sprintf("GetToken for %s failed: %s", dto.Username, err.Error()), r)
 			h.ResErr(w, err)
 			return
 		}
+		metrics.Logins.WithLabelValues("success").Inc()
 		s.Log(LoginSuccessEvent, fmt.Sprintf("%s logged in", user.Username), r)
 		h.ResSuccess(w, map[string]string{
 			"token": token,
@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tomek7667/secrets/internal/metrics"
+	"github.com/tomek7667/secrets/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const metricsCollectTimeout = 5 * time.Second
+
+var (
+	secretsDesc = prometheus.NewDesc("secrets_secrets", "Secrets in the db.", nil, nil)
+	tokensDesc  = prometheus.NewDesc("secrets_tokens", "Api tokens in the db.", nil, nil)
+)
+
+// storeCollector counts the secrets and tokens of the server's db when prometheus scrapes it
+type storeCollector struct {
+	s *Server
+}
+
+func (c storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- secretsDesc
+	ch <- tokensDesc
+}
+
+func (c storeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsCollectTimeout)
+	defer cancel()
+	for desc, count := range map[*prometheus.Desc]func(context.Context) (int64, error){
+		secretsDesc: c.s.Db.CountSecrets,
+		tokensDesc:  c.s.Db.CountTokens,
+	} {
+		n, err := count(ctx)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(n))
+	}
+}
+
+// ServeMetricsOn serves /metrics on its own address, e.g. one only prometheus reaches, instead of the api's
+func (s *Server) ServeMetricsOn(address string) {
+	s.metricsAddress = address
+}
+
+func (s *Server) metricsHandler() http.Handler {
+	return promhttp.HandlerFor(prometheus.Gatherers{metrics.Registry, s.metrics}, promhttp.HandlerOpts{})
+}
+
+// AddMetricsRoutes serves the prometheus metrics, unless they have their own address, see ServeMetricsOn
+func (s *Server) AddMetricsRoutes() {
+	if s.metricsAddress != "" {
+		return
+	}
+	s.Router.Method(http.MethodGet, "/metrics", s.metricsHandler())
+}
+
+func (s *Server) serveMetrics() {
+	if s.metricsAddress == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", s.metricsHandler())
+	fmt.Printf("serving metrics on address '%s'\n", s.metricsAddress)
+	if err := http.ListenAndServe(s.metricsAddress, mux); err != nil {
+		slog.Error("failed to serve metrics", "err", err, "address", s.metricsAddress)
+	}
+}
+
+// statusRecorder keeps the status code of the response, Unwrap lets http.ResponseController flush the streams
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// instrument counts, times and traces the requests by route pattern, the requests no route served
+// (not found, redirected to the primary or forwarded to the leader) are "none". The span continues
+// the trace of the W3C traceparent header of the request, if any.
+func (s *Server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := tracing.Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		r = r.WithContext(ctx)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		route := "none"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		if rec.code == 0 {
+			rec.code = http.StatusOK
+		}
+		metrics.HttpRequests.WithLabelValues(route, r.Method, strconv.Itoa(rec.code)).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", rec.code),
+		)
+		if rec.code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.code))
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package secrets_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s1", Key: "app/db", Value: "djE="}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateToken(ctx, sqlc.CreateTokenParams{ID: "metrics-token", Token: "token"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: "p1", TokenID: "metrics-token", SecretKeyPattern: "app/*"}); err != nil {
+		t.Fatal(err)
+	}
+	serve := func(url string, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", auth)
+		rec := httptest.NewRecorder()
+		srv.Router.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := serve("/api/secrets/get?key=app/db", "Api token"); rec.Code != http.StatusOK {
+		t.Fatalf("expected the secret, got %d", rec.Code)
+	}
+
+	rec := serve("/metrics", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the metrics, got %d", rec.Code)
+	}
+	for _, line := range []string{
+		`secrets_http_requests_total{code="200",method="GET",route="/api/secrets/get"}`,
+		`secrets_token_reads_total{token="metrics-token"} 1`,
+		`secrets_db_query_duration_seconds_count{query="GetSecret"}`,
+		"secrets_secrets 1",
+		"secrets_tokens 1",
+	} {
+		if !strings.Contains(rec.Body.String(), line) {
+			t.Errorf("expected the metrics to have %s", line)
+		}
+	}
+}
@@ -1,23 +1,64 @@
 package secrets
 
-import "strings"
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
 
 const WildCardChar = "*"
 
 func PatternMatches(key, pattern string) bool {
-	if pattern == "" {
-		return key == ""
+	return compilePattern(pattern).matches(key)
+}
+
+// LikePattern is the pattern in SQL, for a LIKE with ESCAPE '\': "*" is "%", while "%", "_" and "\" are escaped,
+// e.g. "app_1/*" is "app\_1/%". sqlite's LIKE ignores the case of ASCII letters, so it may match more keys.
+func LikePattern(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteByte('%')
+		case '%', '_', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
 	}
-	if pattern == WildCardChar {
+	return b.String()
+}
+
+// keyPattern is a pattern split on its wildcards once, instead of on every key it's matched against
+type keyPattern struct {
+	raw string
+	// parts is nil for a pattern without wildcards
+	parts []string
+}
+
+func compilePattern(pattern string) keyPattern {
+	p := keyPattern{raw: pattern}
+	if strings.Contains(pattern, WildCardChar) {
+		p.parts = strings.Split(pattern, WildCardChar)
+	}
+	return p
+}
+
+func (p keyPattern) matches(key string) bool {
+	if p.raw == WildCardChar {
 		return true
 	}
 
-	// No wildcard -> exact match
-	if !strings.Contains(pattern, WildCardChar) {
-		return key == pattern
+	// No wildcard -> exact match, the empty pattern matches the empty key
+	if p.parts == nil {
+		return key == p.raw
 	}
 
-	parts := strings.Split(pattern, WildCardChar)
+	parts := p.parts
 	s := key
 	idx := 0
 
@@ -55,3 +96,67 @@ func PatternMatches(key, pattern string) bool {
 
 	return true
 }
+
+// permissionSet is the compiled patterns of a token's permissions, see Server.permissionSet
+type permissionSet []keyPattern
+
+func compilePermissions(permissions []sqlc.Permission) permissionSet {
+	set := make(permissionSet, len(permissions))
+	for i, permission := range permissions {
+		set[i] = compilePattern(permission.SecretKeyPattern)
+	}
+	return set
+}
+
+// allows tells whether any of the permissions matches the key
+func (set permissionSet) allows(key string) bool {
+	for _, p := range set {
+		if p.matches(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedSecrets reads the secrets the permissions allow, newest first. Every pattern is a LIKE query using
+// the index of the keys, PatternMatches still has the last word on what the query returned.
+func (s *Server) allowedSecrets(ctx context.Context, permissions permissionSet) ([]sqlc.Secret, error) {
+	for _, p := range permissions {
+		if p.raw == WildCardChar {
+			permissions = permissionSet{p}
+			break
+		}
+	}
+	var allowed []sqlc.Secret
+	seen := map[string]bool{}
+	for _, p := range permissions {
+		secrets, err := s.Db.ListSecretsByKeyPattern(ctx, LikePattern(p.raw))
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets {
+			if !seen[secret.ID] && p.matches(secret.Key) {
+				seen[secret.ID] = true
+				allowed = append(allowed, secret)
+			}
+		}
+	}
+	if len(permissions) > 1 {
+		slices.SortStableFunc(allowed, func(a, b sqlc.Secret) int {
+			return compareTimes(b.CreatedAt, a.CreatedAt)
+		})
+	}
+	return allowed, nil
+}
+
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(*b)
+}
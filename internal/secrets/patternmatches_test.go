@@ -1,9 +1,15 @@
 package secrets_test
 
 import (
+	"context"
+	"path/filepath"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/sqlite"
 )
 
 func TestPatternMatchesAllWildCards(t *testing.T) {
@@ -76,3 +82,63 @@ func TestPatternMatchesAllWildCards(t *testing.T) {
 		})
 	}
 }
+
+// FuzzLikePattern checks that the LIKE query of a pattern returns every key PatternMatches matches, and for
+// valid UTF-8 nothing else but the keys matching with another case of ASCII letters (sqlite reads invalid
+// UTF-8 as other characters, which PatternMatches filters out), e.g.
+// go test ./internal/secrets -run ^$ -fuzz LikePattern -fuzztime 30s
+func FuzzLikePattern(f *testing.F) {
+	ctx := context.Background()
+	db, err := sqlite.New(ctx, filepath.Join(f.TempDir(), "secrets.sqlite"), sqlite.Options{})
+	if err != nil {
+		f.Fatal(err)
+	}
+	defer db.Close()
+	for _, seed := range [][2]string{
+		{"app/db", "app/*"},
+		{"app_1/db", "app_1/*"},
+		{"appx1/db", "app_1/*"},
+		{"100%/db", "100%/*"},
+		{"1000/db", "100%/*"},
+		{`a\b`, `a\*`},
+		{"App/db", "app/*"},
+		{"essa/d/a/anything/inside/abc.txt", "*/anything/inside/*.txt"},
+		{"abxxcdyy", "ab*cd"},
+		{"", ""},
+		{"zażółć/gęślą", "za*ść*"},
+		{"\xe0", "\xff"},
+	} {
+		f.Add(seed[0], seed[1])
+	}
+	f.Fuzz(func(t *testing.T, key, pattern string) {
+		if strings.ContainsRune(key+pattern, 0) {
+			t.Skip("postgres rejects NUL in text, sqlite ends a LIKE pattern at it")
+		}
+		if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "fuzz", Key: key, Value: ""}); err != nil {
+			t.Fatal(err)
+		}
+		defer db.DeleteSecret(ctx, key)
+		listed, err := db.ListSecretsByKeyPattern(ctx, secrets.LikePattern(pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := len(listed) == 1
+		if secrets.PatternMatches(key, pattern) && !found {
+			t.Errorf("LIKE %q doesn't return %q, which matches %q", secrets.LikePattern(pattern), key, pattern)
+		}
+		if found && utf8.ValidString(key+pattern) && !secrets.PatternMatches(asciiLower(key), asciiLower(pattern)) {
+			t.Errorf("LIKE %q returns %q, which doesn't match %q in any case", secrets.LikePattern(pattern), key, pattern)
+		}
+	})
+}
+
+// asciiLower lowers only the ASCII letters, like sqlite's LIKE
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,61 @@
+package secrets_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestDeleteCascadesPermissions(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	jwt := "Bearer " + adminJwt(t, db)
+	if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s1", Key: "app/db", Value: "djE="}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"t1", "t2"} {
+		if _, err := db.CreateToken(ctx, sqlc.CreateTokenParams{ID: id, Token: "token-" + id}); err != nil {
+			t.Fatal(err)
+		}
+		for _, pattern := range []string{"app/db", "app/*"} {
+			if _, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: id + pattern, TokenID: id, SecretKeyPattern: pattern}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	permissions := func(tokenID string) int {
+		permissions, err := db.ListPermissionsByTokenId(ctx, tokenID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(permissions)
+	}
+
+	// permissions are patterns, they grant a secret of the key created again
+	if code := serve(srv, http.MethodDelete, "/api/secrets?key=app/db", jwt, "", nil); code != http.StatusOK {
+		t.Fatalf("expected the secret to be deleted, got %d", code)
+	}
+	if n := permissions("t1"); n != 2 {
+		t.Errorf("expected the permissions to outlive the secret, got %d", n)
+	}
+
+	if code := serve(srv, http.MethodDelete, "/api/tokens/t1", jwt, "", nil); code != http.StatusOK {
+		t.Fatalf("expected the token to be deleted, got %d", code)
+	}
+	if n := permissions("t1"); n != 0 {
+		t.Errorf("expected the permissions of the deleted token to be deleted with it, got %d", n)
+	}
+	if n := permissions("t2"); n != 2 {
+		t.Errorf("expected the permissions of the other token to be kept, got %d", n)
+	}
+	orphaned, err := db.ListOrphanedPermissions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphaned) != 0 {
+		t.Errorf("expected no orphaned permissions, got %d", len(orphaned))
+	}
+}
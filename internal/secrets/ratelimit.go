@@ -4,17 +4,22 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/tomek7667/secrets/internal/metrics"
 )
 
 type rateLimiter struct {
+	// name labels the rejections in the metrics
+	name     string
 	requests map[string][]time.Time
 	mu       sync.Mutex
 	limit    int
 	window   time.Duration
 }
 
-func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+func newRateLimiter(name string, limit int, window time.Duration) *rateLimiter {
 	return &rateLimiter{
+		name:     name,
 		requests: make(map[string][]time.Time),
 		limit:    limit,
 		window:   window,
@@ -55,6 +60,7 @@ func withRateLimit(rl *rateLimiter) func(http.Handler) http.Handler {
 			}
 
 			if !rl.isAllowed(ip) {
+				metrics.RateLimited.WithLabelValues(rl.name).Inc()
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusTooManyRequests)
 				w.Write([]byte(`{"success":false,"message":"too many requests"}`))
@@ -0,0 +1,134 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/tomek7667/secrets/internal/replication"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+var errReplicationUnsupported = errors.New("replication ships sqlite databases, replicate postgres with its own tools")
+
+type ReplicationConfig struct {
+	// Secret authenticates followers to the primary and promotion requests to followers
+	Secret string
+	// Follower makes the server a follower of its primary, the db must be the replica it writes, see sqlite.OpenReplica
+	Follower *replication.Follower
+}
+
+// replicationState is either a primary or a follower, until the follower is promoted
+type replicationState struct {
+	secret string
+
+	mu       sync.RWMutex
+	primary  *replication.Primary
+	follower *replication.Follower
+}
+
+func (rs *replicationState) current() (*replication.Primary, *replication.Follower) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.primary, rs.follower
+}
+
+// EnableReplication makes the server ship its transactions to followers, or follow a primary.
+// The writer of a primary's sqlite db needs the pragma wal_autocheckpoint=0, see replication.NewPrimary.
+func (s *Server) EnableReplication(config ReplicationConfig) error {
+	if config.Secret == "" {
+		return fmt.Errorf("a replication secret is required")
+	}
+	c, ok := s.store().(*sqlite.Client)
+	if !ok {
+		return errReplicationUnsupported
+	}
+	state := &replicationState{secret: config.Secret, follower: config.Follower}
+	if config.Follower == nil {
+		primary, err := replication.NewPrimary(context.Background(), c)
+		if err != nil {
+			return err
+		}
+		state.primary = primary
+	} else if !c.Replica() {
+		return fmt.Errorf("the db of a follower must be its replica")
+	}
+	s.replication = state
+	return nil
+}
+
+// following tells whether the server serves a read only replica of its primary
+func (s *Server) following() bool {
+	if s.replication == nil {
+		return false
+	}
+	_, follower := s.replication.current()
+	return follower != nil
+}
+
+func (s *Server) runReplication() {
+	if s.replication == nil {
+		return
+	}
+	primary, follower := s.replication.current()
+	if follower != nil {
+		follower.Start(context.Background())
+		return
+	}
+	primary.Run(context.Background())
+}
+
+// promote stops following the primary and makes the replica writable, so that
+// the server runs the background jobs and other followers can follow it
+func (s *Server) promote(ctx context.Context) error {
+	rs := s.replication
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.follower == nil {
+		return fmt.Errorf("the server is not a follower")
+	}
+	rs.follower.Stop()
+	// EnableReplication has checked the db is a replica
+	c := s.store().(*sqlite.Client)
+	if err := c.Promote(ctx); err != nil {
+		return fmt.Errorf("failed to make the replica writable: %w", err)
+	}
+	primary, err := replication.NewPrimary(ctx, c)
+	if err != nil {
+		return err
+	}
+	rs.follower = nil
+	rs.primary = primary
+	go primary.Run(context.Background())
+	s.runJobs()
+	return nil
+}
+
+// followerRoutes are served by followers from their replica, and by the cluster nodes that aren't the leader
+var followerRoutes = map[string]bool{
+	"GET /api/secrets/get":          true,
+	"GET /api/secrets/list":         true,
+	"GET /api/replication/status":   true,
+	"POST /api/replication/promote": true,
+	"GET /api/cluster/status":       true,
+	"GET /metrics":                  true,
+	"GET /ping":                     true,
+	"GET /healthz":                  true,
+	"GET /readyz":                   true,
+	"GET /version":                  true,
+}
+
+// redirectToPrimary sends the requests a follower can't serve to the primary, 307 keeps the method and the body
+func (s *Server) redirectToPrimary(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, follower := s.replication.current()
+		// a lease is written to the db
+		if follower == nil || (followerRoutes[r.Method+" "+r.URL.Path] && !r.URL.Query().Has("lease")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.Redirect(w, r, follower.Primary+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	})
+}
@@ -0,0 +1,84 @@
+package secrets_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestDeleteSecretDeletesVersions(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	auth := "Bearer " + adminJwt(t, db)
+
+	if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s1", Key: "app/db", Value: "djE="}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateSecretVersion(ctx, sqlc.CreateSecretVersionParams{ID: "v1", SecretKey: "app/db", Version: 1, Value: "djE="}); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(srv, http.MethodDelete, "/api/secrets?key=app/db", auth, "", nil); code != http.StatusOK {
+		t.Fatalf("expected the secret to be deleted, got %d", code)
+	}
+	versions, err := db.ListSecretVersions(ctx, "app/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 {
+		t.Errorf("expected the versions to be deleted with the secret, got %d", len(versions))
+	}
+}
+
+func TestRotationVersionsOutgoingValue(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	auth := "Bearer " + adminJwt(t, db)
+
+	if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s1", Key: "app/db", Value: "aW5pdGlhbA=="}); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := db.CreateRotationPolicy(ctx, sqlc.CreateRotationPolicyParams{
+		ID:              "r1",
+		SecretKey:       "app/db",
+		IntervalSeconds: 3600,
+		Generator:       `{"type":"password","length":16}`,
+		NextRotationAt:  time.Now().UTC().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotate := func() string {
+		var secret sqlc.Secret
+		if code := serve(srv, http.MethodPost, "/api/rotations/"+policy.ID+"/rotate", auth, "", &secret); code != http.StatusOK {
+			t.Fatalf("expected the secret to be rotated, got %d", code)
+		}
+		return secret.Value
+	}
+
+	first := rotate()
+	if code := serve(srv, http.MethodPut, "/api/secrets?key=app/db", auth, `{"value":"manual"}`, nil); code != http.StatusOK {
+		t.Fatalf("expected the secret to be updated, got %d", code)
+	}
+	second := rotate()
+	third := rotate()
+
+	var versions []sqlc.SecretVersion
+	if code := serve(srv, http.MethodGet, "/api/rotations/"+policy.ID+"/versions", auth, "", &versions); code != http.StatusOK {
+		t.Fatalf("expected the versions, got %d", code)
+	}
+	// the initial and the manual values are versioned when they are rotated out, the rotated ones once
+	expected := []string{third, second, "bWFudWFs", first, "aW5pdGlhbA=="}
+	if len(versions) != len(expected) {
+		t.Fatalf("expected %d versions, got %d", len(expected), len(versions))
+	}
+	for i, version := range versions {
+		if version.Value != expected[i] || version.Version != int64(len(expected)-i) {
+			t.Errorf("expected version %d to be %s, got version %d %s", len(expected)-i, expected[i], version.Version, version.Value)
+		}
+	}
+}
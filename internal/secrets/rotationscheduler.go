@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/generator"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+const (
+	rotationPollInterval = time.Minute
+	minRotationInterval  = time.Minute
+)
+
+func (s *Server) runRotationScheduler() {
+	ticker := time.NewTicker(rotationPollInterval)
+	defer ticker.Stop()
+	for {
+		if s.leading() {
+			s.rotateDueSecrets(context.Background())
+		}
+		<-ticker.C
+	}
+}
+
+func (s *Server) rotateDueSecrets(ctx context.Context) {
+	policies, err := s.Db.ListDueRotationPolicies(ctx, time.Now().UTC())
+	if err != nil {
+		slog.Error("failed to list due rotation policies", "err", err)
+		return
+	}
+	for _, policy := range policies {
+		_, err := s.rotateSecret(ctx, policy)
+		if err != nil {
+			s.Log(ErrorEvent, fmt.Sprintf("scheduled rotation of secret %s failed: %s", policy.SecretKey, err.Error()), nil)
+		} else {
+			s.Log(RotateSecretEvent, fmt.Sprintf("scheduler rotated secret %s", policy.SecretKey), nil)
+		}
+	}
+}
+
+// rotateSecret generates a new value for the policy secret, keeps the previous values as
+// versions and notifies watchers and webhooks. The outcome is saved on the policy either way.
+func (s *Server) rotateSecret(ctx context.Context, policy sqlc.RotationPolicy) (sqlc.Secret, error) {
+	now := time.Now().UTC()
+	secret, rotateErr := s.writeRotatedSecret(ctx, policy)
+	params := sqlc.UpdateRotationPolicyResultParams{
+		ID:             policy.ID,
+		LastRotatedAt:  policy.LastRotatedAt,
+		NextRotationAt: now.Add(time.Duration(policy.IntervalSeconds) * time.Second),
+	}
+	if rotateErr != nil {
+		errMsg := rotateErr.Error()
+		params.LastError = &errMsg
+		// don't hammer a broken policy every minute
+		params.NextRotationAt = now.Add(min(time.Duration(policy.IntervalSeconds)*time.Second, time.Hour))
+	} else {
+		params.LastRotatedAt = &now
+	}
+	_, err := s.Db.UpdateRotationPolicyResult(ctx, params)
+	if err != nil {
+		slog.Error("failed to save the rotation result", "err", err, "policy", policy.ID)
+	}
+	if rotateErr != nil {
+		return sqlc.Secret{}, rotateErr
+	}
+	s.secretChanged(ctx, SecretRotatedEvent, secret.Key)
+	return secret, nil
+}
+
+func (s *Server) writeRotatedSecret(ctx context.Context, policy sqlc.RotationPolicy) (sqlc.Secret, error) {
+	var opts generator.Options
+	if err := json.Unmarshal([]byte(policy.Generator), &opts); err != nil {
+		return sqlc.Secret{}, fmt.Errorf("invalid generator options: %w", err)
+	}
+	value, err := generator.Generate(opts)
+	if err != nil {
+		return sqlc.Secret{}, fmt.Errorf("failed to generate a new value: %w", err)
+	}
+	// the versions and the value change together, a failed rotation leaves the secret as it was
+	encoded := utils.B64Encode(value)
+	var secret sqlc.Secret
+	err = s.Db.WithTx(ctx, func(q sqlc.Querier) error {
+		current, err := q.GetSecret(ctx, policy.SecretKey)
+		if err != nil {
+			return fmt.Errorf("failed to get secret %s: %w", policy.SecretKey, err)
+		}
+		newest, err := q.GetNewestSecretVersion(ctx, policy.SecretKey)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get the latest version of %s: %w", policy.SecretKey, err)
+		}
+		version := newest.Version
+		if version == 0 || newest.Value != current.Value {
+			// the outgoing value becomes a version unless it is the last rotated one,
+			// e.g. the value from before the first rotation or one set in between
+			version++
+			_, err = q.CreateSecretVersion(ctx, sqlc.CreateSecretVersionParams{
+				ID:        utils.CreateUUID(),
+				SecretKey: policy.SecretKey,
+				Version:   version,
+				Value:     current.Value,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save the outgoing version of %s: %w", policy.SecretKey, err)
+			}
+		}
+		_, err = q.CreateSecretVersion(ctx, sqlc.CreateSecretVersionParams{
+			ID:        utils.CreateUUID(),
+			SecretKey: policy.SecretKey,
+			Version:   version + 1,
+			Value:     encoded,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save a new version of %s: %w", policy.SecretKey, err)
+		}
+		secret, err = q.UpdateSecret(ctx, sqlc.UpdateSecretParams{
+			Key:   policy.SecretKey,
+			Value: encoded,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update secret %s: %w", policy.SecretKey, err)
+		}
+		return nil
+	})
+	return secret, err
+}
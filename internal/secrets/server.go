@@ -11,11 +11,14 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tomek7667/go-http-helpers/chii"
 	"github.com/tomek7667/go-http-helpers/h"
 	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/audit"
+	"github.com/tomek7667/secrets/internal/cluster"
 	"github.com/tomek7667/secrets/internal/sqlc"
-	"github.com/tomek7667/secrets/internal/sqlite"
+	"github.com/tomek7667/secrets/internal/storage"
 )
 
 type Options struct {
@@ -25,7 +28,7 @@ type Options struct {
 }
 
 type Server struct {
-	Db *sqlite.Client
+	Db storage.Store
 
 	turnstileSecret  string
 	turnstileSiteKey string
@@ -34,16 +37,23 @@ type Server struct {
 	Router           chi.Router
 	auther           Auther
 	loginLimiter     *rateLimiter
+	shareLimiter     *rateLimiter
+	auditSinks       []audit.Sink
+	webhookNudge     chan struct{}
+	watchHub         *watchHub
+	backups          *backupScheduler
+	replication      *replicationState
+	cluster          *cluster.Node
+	cache            *readCache
+	metrics          *prometheus.Registry
+	metricsAddress   string
+	publicUrl        string
 }
 
-func New(address, allowedOrigins, dbPath, jwtSecret, adminPassword, turnstileSecret, turnstileSiteKey string) (*Server, error) {
+// New serves the db, see storage.New
+func New(address, allowedOrigins string, db storage.Store, jwtSecret, adminPassword, turnstileSecret, turnstileSiteKey string) (*Server, error) {
 	ctx := context.Background()
-	// db
 	godotenv.Load()
-	c, err := sqlite.New(ctx, dbPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize sqlite: %w", err)
-	}
 
 	// http
 	r := chi.NewRouter()
@@ -55,18 +65,23 @@ func New(address, allowedOrigins, dbPath, jwtSecret, adminPassword, turnstileSec
 	server := &Server{
 		Address:          address,
 		allowedOrigins:   strings.Split(allowedOrigins, ","),
-		Db:               c,
+		Db:               db,
 		Router:           r,
 		turnstileSecret:  turnstileSecret,
 		turnstileSiteKey: turnstileSiteKey,
 		auther: Auther{
-			Db:        c,
+			Db:        db,
 			JwtSecret: jwtSecret,
 		},
-		loginLimiter: newRateLimiter(5, time.Minute),
+		loginLimiter: newRateLimiter("login", 5, time.Minute),
+		shareLimiter: newRateLimiter("share", 30, time.Minute),
+		webhookNudge: make(chan struct{}, 1),
+		watchHub:     newWatchHub(),
+		metrics:      prometheus.NewRegistry(),
 	}
+	server.metrics.MustRegister(storeCollector{server})
 
-	if users, _ := c.Queries.ListUsers(ctx); len(users) == 0 {
+	if users, _ := db.ListUsers(ctx); len(users) == 0 {
 		// Use provided admin password or generate a random one
 		password := adminPassword
 		if password == "" {
@@ -78,7 +93,7 @@ func New(address, allowedOrigins, dbPath, jwtSecret, adminPassword, turnstileSec
 			Password: password,
 		}
 		slog.Info("no users found; creating admin user", "params", params)
-		_, err := c.Queries.CreateUser(ctx, params)
+		_, err := db.CreateUser(ctx, params)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create the default user: %w", err)
 		}
@@ -87,9 +102,25 @@ func New(address, allowedOrigins, dbPath, jwtSecret, adminPassword, turnstileSec
 	return server, nil
 }
 
+// runJobs starts the background jobs, which write to the db, on a cluster only the leader runs them, see leading
+func (s *Server) runJobs() {
+	go s.runWebhookDispatcher()
+	go s.runRotationScheduler()
+	go s.runExpiryChecker()
+	go s.runLeaseReaper()
+	go s.runShareJanitor()
+	go s.runBackupScheduler()
+}
+
 func (s *Server) Serve() {
 	chii.SetupMiddlewares(s.Router, s.allowedOrigins)
 	s.SetupRoutes()
+	go s.runReplication()
+	go s.serveMetrics()
+	// a follower's replica is read only, it runs them once promoted
+	if !s.following() {
+		s.runJobs()
+	}
 	fmt.Printf("listening on address '%s'\n", s.Address)
 	chii.PrintRoutes(s.Router)
 	err := http.ListenAndServe(s.Address, s.Router)
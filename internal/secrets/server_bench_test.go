@@ -0,0 +1,143 @@
+package secrets_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/sqlite"
+)
+
+// BenchmarkSecretsGetUnderAuditWrites reads a secret from parallel api clients while writers flood the log table,
+// e.g. go test ./internal/secrets -bench SecretsGet -cpu 8. The rollback journal shows what WAL is for.
+func BenchmarkSecretsGetUnderAuditWrites(b *testing.B) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, scenario := range []struct {
+		name string
+		opts sqlite.Options
+	}{
+		{"wal", sqlite.Options{}},
+		{"rollback-journal", sqlite.Options{Pragmas: []string{"journal_mode=DELETE"}}},
+	} {
+		b.Run(scenario.name, func(b *testing.B) {
+			ctx := context.Background()
+			db, err := sqlite.New(ctx, filepath.Join(b.TempDir(), "secrets.sqlite"), scenario.opts)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.Close()
+			srv, err := secrets.New("", "", db, "jwt", "pw", "", "")
+			if err != nil {
+				b.Fatal(err)
+			}
+			srv.SetupRoutes()
+			if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s1", Key: "bench/secret", Value: "czNjcjN0"}); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := db.CreateToken(ctx, sqlc.CreateTokenParams{ID: "t1", Token: "bench-token"}); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: "p1", TokenID: "t1", SecretKeyPattern: "bench/*"}); err != nil {
+				b.Fatal(err)
+			}
+
+			stop := make(chan struct{})
+			var writers sync.WaitGroup
+			var writes atomic.Int64
+			for w := range 4 {
+				writers.Add(1)
+				go func() {
+					defer writers.Done()
+					for i := 0; ; i++ {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						_, err := db.CreateLog(ctx, sqlc.CreateLogParams{ID: fmt.Sprintf("%d-%d", w, i), Event: "bench", Msg: "audit write"})
+						if err == nil {
+							writes.Add(1)
+						}
+					}
+				}()
+			}
+
+			var failures atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					req := httptest.NewRequest(http.MethodGet, "/api/secrets/get?key=bench/secret", nil)
+					req.Header.Set("Authorization", "Api bench-token")
+					rec := httptest.NewRecorder()
+					srv.Router.ServeHTTP(rec, req)
+					if rec.Code != http.StatusOK {
+						failures.Add(1)
+					}
+				}
+			})
+			b.StopTimer()
+			close(stop)
+			writers.Wait()
+			b.ReportMetric(float64(failures.Load())/float64(b.N), "failures/op")
+			b.ReportMetric(float64(writes.Load())/b.Elapsed().Seconds(), "audit-writes/s")
+		})
+	}
+}
+
+// BenchmarkCache compares the api token reads with and without the cache of tokens, permissions and secrets,
+// e.g. go test ./internal/secrets -bench Cache -run ^$
+func BenchmarkCache(b *testing.B) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, ttl := range []time.Duration{0, time.Minute} {
+		ctx := context.Background()
+		srv, db := newTestServer(b)
+		srv.EnableCache(ttl)
+		srv.SetupRoutes()
+		if _, err := db.CreateToken(ctx, sqlc.CreateTokenParams{ID: "t1", Token: "bench-token"}); err != nil {
+			b.Fatal(err)
+		}
+		for i := range 500 {
+			if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: fmt.Sprintf("s%d", i), Key: fmt.Sprintf("app%d/secret/%d", i%20, i), Value: "czNjcjN0"}); err != nil {
+				b.Fatal(err)
+			}
+		}
+		for i := range 10 {
+			if _, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: fmt.Sprintf("p%d", i), TokenID: "t1", SecretKeyPattern: fmt.Sprintf("app%d/*/*", i)}); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		name := "uncached"
+		if ttl > 0 {
+			name = "cached"
+		}
+		for _, route := range []struct{ name, url string }{
+			{"get", "/api/secrets/get?key=app9/secret/9"},
+			{"list", "/api/secrets/list"},
+		} {
+			b.Run(name+"/"+route.name, func(b *testing.B) {
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						req := httptest.NewRequest(http.MethodGet, route.url, nil)
+						req.Header.Set("Authorization", "Api bench-token")
+						rec := httptest.NewRecorder()
+						srv.Router.ServeHTTP(rec, req)
+						if rec.Code != http.StatusOK {
+							b.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+						}
+					}
+				})
+			})
+		}
+	}
+}
@@ -1,10 +1,28 @@
 package secrets
 
 func (s *Server) SetupRoutes() {
+	s.Router.Use(s.instrument)
+	if s.replication != nil {
+		s.Router.Use(s.redirectToPrimary)
+	}
+	if s.cluster != nil {
+		s.Router.Use(s.forwardToLeader)
+	}
+	s.AddHealthRoutes()
 	s.AddFrontendRoutes()
 	s.PostLogin()
 	s.AddUsersRoutes()
 	s.AddSecretsRoutes()
 	s.AddTokensRoutes()
 	s.AddPermissionsRoutes()
+	s.AddWebhooksRoutes()
+	s.AddRotationsRoutes()
+	s.AddDynamicRoutes()
+	s.AddLeasesRoutes()
+	s.AddSharesRoutes()
+	s.AddBackupRoutes()
+	s.AddAdminRoutes()
+	s.AddReplicationRoutes()
+	s.AddClusterRoutes()
+	s.AddMetricsRoutes()
 }
@@ -0,0 +1,23 @@
+package secrets
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const shareCleanupInterval = 10 * time.Minute
+
+// runShareJanitor deletes expired shares, so that their ciphertext doesn't outlive them
+func (s *Server) runShareJanitor() {
+	ticker := time.NewTicker(shareCleanupInterval)
+	defer ticker.Stop()
+	for {
+		if s.leading() {
+			if err := s.Db.DeleteExpiredShares(context.Background(), time.Now().UTC()); err != nil {
+				slog.Error("failed to delete expired shares", "err", err)
+			}
+		}
+		<-ticker.C
+	}
+}
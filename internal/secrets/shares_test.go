@@ -0,0 +1,124 @@
+package secrets_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/secrets"
+)
+
+// openShare decrypts like the /s/{id} page does in the browser
+func openShare(id string, key, nonce, ciphertext []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(id))
+	return string(plaintext), err
+}
+
+func TestSealShare(t *testing.T) {
+	key, nonce, ciphertext, err := secrets.SealShare("share1", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 {
+		t.Errorf("expected an AES-256 key, got %d bytes", len(key))
+	}
+	value, err := openShare("share1", key, nonce, ciphertext)
+	if err != nil || value != "hunter2" {
+		t.Fatalf("expected the value to round-trip, got %q %v", value, err)
+	}
+	// the id is authenticated, a ciphertext moved to another share doesn't open
+	if _, err := openShare("share2", key, nonce, ciphertext); err == nil {
+		t.Error("expected the ciphertext not to open under another id")
+	}
+
+	otherKey, _, _, err := secrets.SealShare("share1", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key, otherKey) {
+		t.Error("expected every share to get a new key")
+	}
+}
+
+func TestShareRevealedOnce(t *testing.T) {
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	jwt := adminJwt(t, db)
+	do := func(method, url, body string, data any) int {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+jwt)
+		req.Header.Set("Content-Type", "application/json")
+		req.Host = "attacker.example.com"
+		rec := httptest.NewRecorder()
+		srv.Router.ServeHTTP(rec, req)
+		json.NewDecoder(rec.Body).Decode(&struct{ Data any }{Data: data})
+		return rec.Code
+	}
+
+	var share secrets.CreatedShare
+	if code := do(http.MethodPost, "/api/shares", `{"value":"hunter2"}`, &share); code != http.StatusOK {
+		t.Fatalf("expected the share to be created, got %d", code)
+	}
+	if !strings.HasPrefix(share.Url, "/s/"+share.ID+"#") {
+		t.Errorf("expected a relative url without a public url, got %s", share.Url)
+	}
+	if err := srv.SetPublicUrl("https://secrets.example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	if code := do(http.MethodPost, "/api/shares", `{"value":"hunter2"}`, &share); code != http.StatusOK {
+		t.Fatalf("expected the share to be created, got %d", code)
+	}
+	fragment, found := strings.CutPrefix(share.Url, "https://secrets.example.com/s/"+share.ID+"#")
+	if !found {
+		t.Fatalf("expected the url to be built from the public url, got %s", share.Url)
+	}
+	key, err := base64.RawURLEncoding.DecodeString(fragment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var revealed secrets.RevealedShare
+	if code := do(http.MethodPost, "/api/shares/"+share.ID+"/reveal", "", &revealed); code != http.StatusOK {
+		t.Fatalf("expected the share to be revealed, got %d", code)
+	}
+	nonce, _ := base64.StdEncoding.DecodeString(revealed.Nonce)
+	ciphertext, _ := base64.StdEncoding.DecodeString(revealed.Ciphertext)
+	if value, err := openShare(share.ID, key, nonce, ciphertext); err != nil || value != "hunter2" {
+		t.Errorf("expected the key of the url to open the share, got %q %v", value, err)
+	}
+	if code := do(http.MethodPost, "/api/shares/"+share.ID+"/reveal", "", &revealed); code != http.StatusNotFound {
+		t.Errorf("expected the second reveal of a one-time share to be not found, got %d", code)
+	}
+}
+
+func TestSharePage(t *testing.T) {
+	srv, _ := newTestServer(t)
+	srv.SetupRoutes()
+	req := httptest.NewRequest(http.MethodGet, "/s/some-id", nil)
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("expected the dashboard page, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	// the page must neither be cached nor sent as the referrer
+	if v := rec.Header().Get("Cache-Control"); v != "no-store" {
+		t.Errorf("expected Cache-Control no-store, got %q", v)
+	}
+	if v := rec.Header().Get("Referrer-Policy"); v != "no-referrer" {
+		t.Errorf("expected Referrer-Policy no-referrer, got %q", v)
+	}
+}
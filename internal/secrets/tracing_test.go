@@ -0,0 +1,62 @@
+package secrets_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	if _, err := db.CreateSecret(ctx, sqlc.CreateSecretParams{ID: "s1", Key: "app/db", Value: "djE="}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateToken(ctx, sqlc.CreateTokenParams{ID: "t1", Token: "token"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: "p1", TokenID: "t1", SecretKeyPattern: "app/*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	const traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/secrets/get?key=app/db", nil)
+	req.Header.Set("Authorization", "Api token")
+	req.Header.Set("traceparent", "00-"+traceId+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	srv.Router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the secret, got %d", rec.Code)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	request, ok := spans["GET /api/secrets/get"]
+	if !ok {
+		t.Fatalf("expected a span of the route, got %v", spans)
+	}
+	if request.SpanContext().TraceID().String() != traceId {
+		t.Errorf("expected the span to continue the trace of the traceparent header, got %s", request.SpanContext().TraceID())
+	}
+	query, ok := spans["GetSecret"]
+	if !ok {
+		t.Fatalf("expected a span of the GetSecret query, got %v", spans)
+	}
+	if query.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("expected the query span to be a child of the route span")
+	}
+}
@@ -0,0 +1,57 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/tomek7667/secrets/internal/metrics"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+// Tx is a transaction of a multi-step change. The entries of its Log are saved in it,
+// so that a committed change always has its log row, and reach the audit sinks after the commit.
+type Tx struct {
+	sqlc.Querier
+
+	r       *http.Request
+	entries []txLogEntry
+}
+
+type txLogEntry struct {
+	event LogEvent
+	entry sqlc.Log
+}
+
+// Log saves the event in the transaction, unlike Server.Log a failure fails the transaction
+func (tx *Tx) Log(ctx context.Context, event LogEvent, msg string) error {
+	entry, err := tx.CreateLog(ctx, logParams(event, msg, tx.r))
+	if err != nil {
+		return fmt.Errorf("failed to save the log entry: %w", err)
+	}
+	tx.entries = append(tx.entries, txLogEntry{event: event, entry: entry})
+	return nil
+}
+
+// withTx runs fn in a transaction, rolled back when fn returns an error. r is nil for background jobs.
+// fn must not use s.Db, the single sqlite writer connection is busy with the transaction.
+func (s *Server) withTx(ctx context.Context, r *http.Request, fn func(tx *Tx) error) error {
+	tx := &Tx{r: r}
+	err := s.Db.WithTx(ctx, func(q sqlc.Querier) error {
+		tx.Querier = q
+		return fn(tx)
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range tx.entries {
+		slog.Debug(e.entry.Msg, "event", e.event)
+		metrics.AuditQueue.Inc()
+		go func() {
+			defer metrics.AuditQueue.Dec()
+			s.writeToAuditSinks(context.WithoutCancel(ctx), e.event, e.entry)
+		}()
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	logged := func(msg string) bool {
+		logs, err := db.ListLogs(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, log := range logs {
+			if log.Msg == msg {
+				return true
+			}
+		}
+		return false
+	}
+	create := func(key string, fail error) error {
+		return srv.WithTx(ctx, func(tx *secrets.Tx) error {
+			if _, err := tx.CreateSecret(ctx, sqlc.CreateSecretParams{ID: key, Key: key, Value: "djE="}); err != nil {
+				return err
+			}
+			if err := tx.Log(ctx, secrets.IngestEvent, "created "+key); err != nil {
+				return err
+			}
+			return fail
+		})
+	}
+
+	failure := errors.New("failed after the log")
+	if err := create("app/failed", failure); !errors.Is(err, failure) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+	if _, err := db.GetSecret(ctx, "app/failed"); err == nil {
+		t.Error("expected the secret of the failed transaction to be rolled back")
+	}
+	if logged("created app/failed") {
+		t.Error("expected the log row of the failed transaction to be rolled back")
+	}
+
+	if err := create("app/committed", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetSecret(ctx, "app/committed"); err != nil {
+		t.Errorf("expected the secret to be committed, got %v", err)
+	}
+	if !logged("created app/committed") {
+		t.Error("expected the log row to be committed with the secret")
+	}
+}
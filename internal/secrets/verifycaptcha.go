@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/tomek7667/secrets/internal/tracing"
 )
 
 type turnstileResp struct {
@@ -15,7 +17,9 @@ type turnstileResp struct {
 	ErrorCodes []string `json:"error-codes,omitempty"`
 }
 
-func (s *Server) verifyCaptcha(ctx context.Context, token string) error {
+func (s *Server) verifyCaptcha(ctx context.Context, token string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "captcha.verify")
+	defer func() { tracing.End(span, err) }()
 	if s.turnstileSecret == "" {
 		slog.Info("Skipping captcha verification: turnstile secret not configured. Set via -turnstile-secret flag or TURNSTILE_SECRET environment variable.")
 		return nil
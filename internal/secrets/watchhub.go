@@ -0,0 +1,58 @@
+package secrets
+
+import (
+	"sync"
+	"time"
+)
+
+const watchSubscriberBuffer = 64
+
+type SecretChange struct {
+	Event SecretEvent `json:"event"`
+	Key   string      `json:"key"`
+	Ts    time.Time   `json:"ts"`
+}
+
+// watchHub fans out secret changes to the connected /api/secrets/watch streams.
+type watchHub struct {
+	mu          sync.Mutex
+	subscribers map[chan SecretChange]struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{
+		subscribers: make(map[chan SecretChange]struct{}),
+	}
+}
+
+func (wh *watchHub) subscribe() chan SecretChange {
+	ch := make(chan SecretChange, watchSubscriberBuffer)
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	wh.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (wh *watchHub) unsubscribe(ch chan SecretChange) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	if _, ok := wh.subscribers[ch]; ok {
+		delete(wh.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish never blocks. A subscriber that can't keep up is disconnected, so
+// that it reconnects and re-reads its secrets instead of silently missing a change.
+func (wh *watchHub) publish(change SecretChange) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	for ch := range wh.subscribers {
+		select {
+		case ch <- change:
+		default:
+			delete(wh.subscribers, ch)
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package secrets_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestWatchFiltersEvents(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+	srv.SetupRoutes()
+	server := httptest.NewServer(srv.Router)
+	defer server.Close()
+	if _, err := db.CreateToken(ctx, sqlc.CreateTokenParams{ID: "t1", Token: "token"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreatePermission(ctx, sqlc.CreatePermissionParams{ID: "p1", TokenID: "t1", SecretKeyPattern: "app/*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, server.URL+"/api/secrets/watch?pattern=*db", nil)
+	req.Header.Set("Authorization", "Api token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the stream to open, got %d", resp.StatusCode)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	// the subscription exists once the retry line is sent
+	if !lines.Scan() || lines.Text() != "retry: 3000" {
+		t.Fatalf("expected the retry line, got %q", lines.Text())
+	}
+
+	for _, key := range []string{"web/db", "app/cache", "app/db"} {
+		srv.PublishSecretChange(secrets.SecretChange{Event: secrets.SecretUpdatedEvent, Key: key, Ts: time.Now()})
+	}
+	var data string
+	for lines.Scan() {
+		if after, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+			data = after
+			break
+		}
+	}
+	var change secrets.SecretChange
+	if err := json.Unmarshal([]byte(data), &change); err != nil {
+		t.Fatalf("expected an event, got %q: %v", data, err)
+	}
+	// web/db isn't permitted and app/cache doesn't match the pattern
+	if change.Key != "app/db" || change.Event != secrets.SecretUpdatedEvent {
+		t.Errorf("expected the first event to be the update of app/db, got %s of %s", change.Event, change.Key)
+	}
+}
+
+func TestWatchHubDisconnectsSlowSubscribers(t *testing.T) {
+	hub := secrets.NewWatchHub()
+	slow := hub.Subscribe()
+	fast := hub.Subscribe()
+
+	for i := range secrets.WatchSubscriberBuffer + 1 {
+		hub.Publish(secrets.SecretChange{Event: secrets.SecretUpdatedEvent, Key: "app/db", Ts: time.Unix(int64(i), 0)})
+		if i < secrets.WatchSubscriberBuffer {
+			<-fast
+		}
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != secrets.WatchSubscriberBuffer {
+		t.Errorf("expected the slow subscriber to get the %d buffered changes before being closed, got %d", secrets.WatchSubscriberBuffer, received)
+	}
+	select {
+	case _, open := <-fast:
+		if !open {
+			t.Error("expected the subscriber that kept up to stay connected")
+		}
+	default:
+		t.Error("expected the subscriber that kept up to get the last change")
+	}
+}
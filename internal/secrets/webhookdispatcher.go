@@ -0,0 +1,219 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+type SecretEvent string
+
+const (
+	SecretCreatedEvent SecretEvent = "created"
+	SecretUpdatedEvent SecretEvent = "updated"
+	SecretDeletedEvent SecretEvent = "deleted"
+	SecretRotatedEvent SecretEvent = "rotated"
+)
+
+func (se SecretEvent) String() string {
+	return string(se)
+}
+
+const (
+	webhookMaxAttempts  = 10
+	webhookBaseDelay    = 10 * time.Second
+	webhookMaxDelay     = time.Hour
+	webhookPollInterval = 5 * time.Second
+	webhookBatchSize    = 100
+
+	webhookStatusPending   = "pending"
+	webhookStatusDelivered = "delivered"
+	webhookStatusFailed    = "failed"
+)
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookPayload never contains the secret value, subscribers have to fetch it with their own token.
+type webhookPayload struct {
+	ID    string    `json:"id"`
+	Event string    `json:"event"`
+	Key   string    `json:"key"`
+	Ts    time.Time `json:"ts"`
+}
+
+func webhookSubscribed(webhook sqlc.Webhook, event SecretEvent, key string) bool {
+	return slices.Contains(strings.Split(webhook.Events, ","), event.String()) &&
+		PatternMatches(key, webhook.KeyPattern)
+}
+
+// webhookSignature is the hex encoded HMAC-SHA256 of the payload, sent as "X-Secrets-Signature: sha256=<signature>"
+func webhookSignature(signingSecret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// secretChanged is called after every successful secret write. It notifies the watch
+// streams and queues deliveries for all matching webhooks.
+func (s *Server) secretChanged(ctx context.Context, event SecretEvent, key string) {
+	s.watchHub.publish(SecretChange{
+		Event: event,
+		Key:   key,
+		Ts:    time.Now().UTC(),
+	})
+
+	if event != SecretCreatedEvent {
+		// holders of the previous value can be told apart from the ones that read the new value
+		staleAt := time.Now().UTC()
+		if err := s.Db.MarkLeasesStale(ctx, sqlc.MarkLeasesStaleParams{SecretKey: &key, StaleAt: &staleAt}); err != nil {
+			slog.Error("failed to mark leases stale", "err", err, "event", event, "key", key)
+		}
+	}
+
+	webhooks, err := s.Db.ListWebhooks(ctx)
+	if err != nil {
+		slog.Error("failed to list webhooks", "err", err, "event", event, "key", key)
+		return
+	}
+	now := time.Now().UTC()
+	queued := false
+	for _, webhook := range webhooks {
+		if !webhookSubscribed(webhook, event, key) {
+			continue
+		}
+		id := utils.CreateUUID()
+		_, err := s.Db.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+			ID:        id,
+			WebhookID: webhook.ID,
+			Event:     event.String(),
+			SecretKey: key,
+			Payload: utils.MustMarshal(webhookPayload{
+				ID:    id,
+				Event: event.String(),
+				Key:   key,
+				Ts:    now,
+			}),
+			NextAttemptAt: now,
+		})
+		if err != nil {
+			slog.Error("failed to queue webhook delivery", "err", err, "webhook", webhook.ID, "key", key)
+			continue
+		}
+		queued = true
+	}
+	if queued {
+		select {
+		case s.webhookNudge <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *Server) runWebhookDispatcher() {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		if s.leading() {
+			s.deliverDueWebhooks(context.Background())
+		}
+		select {
+		case <-ticker.C:
+		case <-s.webhookNudge:
+		}
+	}
+}
+
+func (s *Server) deliverDueWebhooks(ctx context.Context) {
+	deliveries, err := s.Db.ListDueWebhookDeliveries(ctx, sqlc.ListDueWebhookDeliveriesParams{
+		NextAttemptAt: time.Now().UTC(),
+		Limit:         webhookBatchSize,
+	})
+	if err != nil {
+		slog.Error("failed to list due webhook deliveries", "err", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		s.deliverWebhook(ctx, delivery)
+	}
+}
+
+func (s *Server) deliverWebhook(ctx context.Context, delivery sqlc.WebhookDelivery) {
+	params := sqlc.UpdateWebhookDeliveryAttemptParams{
+		ID:            delivery.ID,
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: delivery.NextAttemptAt,
+	}
+	webhook, err := s.Db.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		errMsg := fmt.Sprintf("webhook %s no longer exists", delivery.WebhookID)
+		params.Status = webhookStatusFailed
+		params.LastError = &errMsg
+	} else {
+		statusCode, err := postWebhook(ctx, webhook, delivery)
+		if statusCode != 0 {
+			params.LastStatusCode = &statusCode
+		}
+		switch {
+		case err == nil:
+			now := time.Now().UTC()
+			params.Status = webhookStatusDelivered
+			params.DeliveredAt = &now
+		case params.Attempts >= webhookMaxAttempts:
+			errMsg := err.Error()
+			params.Status = webhookStatusFailed
+			params.LastError = &errMsg
+			s.Log(WebhookFailedEvent, fmt.Sprintf("gave up delivering %s of secret %s to webhook %s after %d attempts: %s", delivery.Event, delivery.SecretKey, webhook.ID, params.Attempts, errMsg), nil)
+		default:
+			errMsg := err.Error()
+			params.Status = webhookStatusPending
+			params.LastError = &errMsg
+			params.NextAttemptAt = time.Now().UTC().Add(webhookBackoff(params.Attempts))
+		}
+	}
+	_, err = s.Db.UpdateWebhookDeliveryAttempt(ctx, params)
+	if err != nil {
+		slog.Error("failed to update webhook delivery", "err", err, "delivery", delivery.ID)
+	}
+}
+
+// webhookBackoff returns the delay before the next attempt: 10s, 20s, 40s, ... capped at an hour
+func webhookBackoff(attempts int64) time.Duration {
+	delay := webhookBaseDelay
+	for i := int64(1); i < attempts && delay < webhookMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxDelay)
+}
+
+func postWebhook(ctx context.Context, webhook sqlc.Webhook, delivery sqlc.WebhookDelivery) (int64, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Secrets-Event", delivery.Event)
+	req.Header.Set("X-Secrets-Delivery", delivery.ID)
+	req.Header.Set("X-Secrets-Signature", "sha256="+webhookSignature(webhook.SigningSecret, payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return int64(resp.StatusCode), fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return int64(resp.StatusCode), nil
+}
@@ -0,0 +1,199 @@
+package secrets_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tomek7667/secrets/internal/secrets"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	scenarios := map[string]struct {
+		Secret    string
+		Payload   string
+		Signature string
+	}{
+		"empty": {
+			Secret:    "",
+			Payload:   "",
+			Signature: "b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad",
+		},
+		"rfc 4231 case 2": {
+			Secret:    "Jefe",
+			Payload:   "what do ya want for nothing?",
+			Signature: "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		"payload": {
+			Secret:    "whsec",
+			Payload:   `{"id":"d1","event":"created","key":"app/db","ts":"2026-01-01T00:00:00Z"}`,
+			Signature: hmacHex("whsec", `{"id":"d1","event":"created","key":"app/db","ts":"2026-01-01T00:00:00Z"}`),
+		},
+	}
+	for name, sc := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			if got := secrets.WebhookSignature(sc.Secret, []byte(sc.Payload)); got != sc.Signature {
+				t.Errorf("expected %s, got %s", sc.Signature, got)
+			}
+		})
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	scenarios := map[int64]time.Duration{
+		0:  10 * time.Second,
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		3:  40 * time.Second,
+		9:  2560 * time.Second,
+		10: time.Hour,
+		50: time.Hour,
+	}
+	for attempts, expected := range scenarios {
+		if got := secrets.WebhookBackoff(attempts); got != expected {
+			t.Errorf("expected %s after %d attempts, got %s", expected, attempts, got)
+		}
+	}
+}
+
+func TestWebhookSubscribed(t *testing.T) {
+	webhook := sqlc.Webhook{Events: "created,deleted", KeyPattern: "app/*"}
+	scenarios := map[string]struct {
+		Event      secrets.SecretEvent
+		Key        string
+		Subscribed bool
+	}{
+		"matching":    {Event: secrets.SecretCreatedEvent, Key: "app/db", Subscribed: true},
+		"other event": {Event: secrets.SecretUpdatedEvent, Key: "app/db", Subscribed: false},
+		"other key":   {Event: secrets.SecretDeletedEvent, Key: "web/db", Subscribed: false},
+		"neither":     {Event: secrets.SecretRotatedEvent, Key: "web/db", Subscribed: false},
+	}
+	for name, sc := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			if got := secrets.WebhookSubscribed(webhook, sc.Event, sc.Key); got != sc.Subscribed {
+				t.Errorf("expected %v, got %v", sc.Subscribed, got)
+			}
+		})
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	ctx := context.Background()
+	srv, db := newTestServer(t)
+
+	type request struct {
+		Signature string
+		Event     string
+		Body      string
+	}
+	requests := make(chan request, 10)
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{
+			Signature: r.Header.Get("X-Secrets-Signature"),
+			Event:     r.Header.Get("X-Secrets-Event"),
+			Body:      string(body),
+		}
+	}))
+	defer subscriber.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	for id, url := range map[string]string{"ok": subscriber.URL, "failing": failing.URL} {
+		_, err := db.CreateWebhook(ctx, sqlc.CreateWebhookParams{
+			ID:            id,
+			Url:           url,
+			KeyPattern:    "app/*",
+			Events:        "created",
+			SigningSecret: "whsec",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	queue := func(id, webhookID string, attempts int64) {
+		_, err := db.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+			ID:            id,
+			WebhookID:     webhookID,
+			Event:         "created",
+			SecretKey:     "app/db",
+			Payload:       `{"id":"` + id + `","event":"created","key":"app/db"}`,
+			NextAttemptAt: time.Now().UTC().Add(-time.Second),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempts > 0 {
+			_, err = db.UpdateWebhookDeliveryAttempt(ctx, sqlc.UpdateWebhookDeliveryAttemptParams{
+				ID:            id,
+				Status:        "pending",
+				Attempts:      attempts,
+				NextAttemptAt: time.Now().UTC().Add(-time.Second),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	delivery := func(webhookID, id string) sqlc.WebhookDelivery {
+		deliveries, err := db.ListWebhookDeliveries(ctx, sqlc.ListWebhookDeliveriesParams{WebhookID: webhookID, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range deliveries {
+			if d.ID == id {
+				return d
+			}
+		}
+		t.Fatalf("delivery %s not found", id)
+		return sqlc.WebhookDelivery{}
+	}
+
+	queue("d1", "ok", 0)
+	queue("d2", "failing", 0)
+	queue("d3", "failing", secrets.WebhookMaxAttempts-1)
+	srv.DeliverDueWebhooks()
+
+	select {
+	case req := <-requests:
+		if req.Event != "created" {
+			t.Errorf("expected the created event, got %q", req.Event)
+		}
+		if expected := "sha256=" + hmacHex("whsec", req.Body); req.Signature != expected {
+			t.Errorf("expected the signature %s, got %s", expected, req.Signature)
+		}
+	default:
+		t.Fatal("expected the subscriber to receive the delivery")
+	}
+	if d := delivery("ok", "d1"); d.Status != "delivered" || d.DeliveredAt == nil {
+		t.Errorf("expected d1 to be delivered, got %s", d.Status)
+	}
+
+	// a failed attempt is retried later, until the attempts run out
+	d2 := delivery("failing", "d2")
+	if d2.Status != "pending" || d2.Attempts != 1 || d2.LastStatusCode == nil || *d2.LastStatusCode != http.StatusInternalServerError {
+		t.Errorf("expected d2 to be pending after 1 attempt with the status code 500, got %s after %d", d2.Status, d2.Attempts)
+	}
+	if !d2.NextAttemptAt.After(time.Now().UTC()) {
+		t.Errorf("expected d2 to be retried later, got %s", d2.NextAttemptAt)
+	}
+	d3 := delivery("failing", "d3")
+	if d3.Status != "failed" || d3.Attempts != secrets.WebhookMaxAttempts || d3.LastError == nil {
+		t.Errorf("expected d3 to fail after %d attempts, got %s after %d", secrets.WebhookMaxAttempts, d3.Status, d3.Attempts)
+	}
+}
+
+func hmacHex(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,127 @@
+package secretsio
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"gopkg.in/yaml.v3"
+)
+
+// ShellFormat is export only, it renders "export KEY='value'" lines to be sourced
+const ShellFormat Format = "shell"
+
+type ExportOptions struct {
+	// Prefix limits the export to the keys starting with it
+	Prefix string
+	// StripPrefix removes the prefix from the exported keys, e.g. "app/prod/DB_URL" becomes "DB_URL"
+	StripPrefix bool
+	// EnvNames transforms the keys with EnvName
+	EnvNames bool
+}
+
+var (
+	envNameRe        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	envNameInvalidRe = regexp.MustCompile(`[^A-Z0-9_]`)
+)
+
+// EnvName turns a key into an environment variable name, e.g. "aws/access-key" becomes "AWS_ACCESS_KEY"
+func EnvName(key string) string {
+	name := envNameInvalidRe.ReplaceAllString(strings.ToUpper(key), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// ContentType of the exported document
+func ContentType(format Format) string {
+	switch format {
+	case JsonFormat:
+		return "application/json"
+	case YamlFormat:
+		return "application/yaml"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Export renders the secrets in the format. It fails when two keys end up with the same name after
+// stripping the prefix or the env name transformation, or when a key isn't a valid env or shell name.
+func Export(format Format, secrets []sqlc.Secret, opts ExportOptions) ([]byte, error) {
+	values, err := decodeValues(secrets)
+	if err != nil {
+		return nil, err
+	}
+	exported := map[string]string{}
+	origins := map[string]string{}
+	for key, value := range values {
+		if !strings.HasPrefix(key, opts.Prefix) {
+			continue
+		}
+		name := key
+		if opts.StripPrefix {
+			name = strings.TrimPrefix(key, opts.Prefix)
+		}
+		if opts.EnvNames {
+			name = EnvName(name)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("key '%s' is empty after stripping the prefix", key)
+		}
+		if origin, exists := origins[name]; exists {
+			return nil, fmt.Errorf("keys '%s' and '%s' are both exported as '%s'", origin, key, name)
+		}
+		origins[name] = key
+		exported[name] = value
+	}
+	names := make([]string, 0, len(exported))
+	for name := range exported {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch format {
+	case EnvFormat, ShellFormat:
+		var b strings.Builder
+		for _, name := range names {
+			if !envNameRe.MatchString(name) {
+				return nil, fmt.Errorf("'%s' isn't a valid variable name, enable env names to transform it", name)
+			}
+			if format == EnvFormat {
+				fmt.Fprintf(&b, "%s=\"%s\"\n", name, envQuoteReplacer.Replace(exported[name]))
+			} else {
+				fmt.Fprintf(&b, "export %s='%s'\n", name, strings.ReplaceAll(exported[name], "'", `'\''`))
+			}
+		}
+		return []byte(b.String()), nil
+	case JsonFormat:
+		// map keys are sorted by encoding/json
+		data, err := json.MarshalIndent(exported, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case YamlFormat:
+		if len(exported) == 0 {
+			return []byte("{}\n"), nil
+		}
+		return yaml.Marshal(exported)
+	default:
+		return nil, fmt.Errorf("unknown format '%s', expected %s, %s, %s or %s", format, EnvFormat, JsonFormat, YamlFormat, ShellFormat)
+	}
+}
+
+// envQuoteReplacer escapes the characters that godotenv treats specially in double quoted values
+var envQuoteReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"\n", `\n`,
+	"\r", `\r`,
+	`"`, `\"`,
+	`!`, `\!`,
+	`$`, `\$`,
+	"`", "\\`",
+)
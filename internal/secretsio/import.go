@@ -0,0 +1,138 @@
+package secretsio
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/storage"
+)
+
+// ConflictPolicy decides what happens to imported keys that already exist with a different value
+type ConflictPolicy string
+
+const (
+	SkipPolicy      ConflictPolicy = "skip"
+	OverwritePolicy ConflictPolicy = "overwrite"
+	FailPolicy      ConflictPolicy = "fail"
+)
+
+type Action string
+
+const (
+	CreateAction    Action = "create"
+	OverwriteAction Action = "overwrite"
+	SkipAction      Action = "skip"
+	UnchangedAction Action = "unchanged"
+)
+
+// Change describes what an import does to a key. Values are left out, so that a dry run can be shown safely.
+type Change struct {
+	Key    string `json:"key"`
+	Action Action `json:"action"`
+}
+
+var ErrConflict = errors.New("secret already exists with a different value")
+
+func ParseConflictPolicy(policy string) (ConflictPolicy, error) {
+	switch ConflictPolicy(policy) {
+	case "":
+		return FailPolicy, nil
+	case SkipPolicy, OverwritePolicy, FailPolicy:
+		return ConflictPolicy(policy), nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy '%s', expected %s, %s or %s", policy, SkipPolicy, OverwritePolicy, FailPolicy)
+	}
+}
+
+// Plan compares the incoming values with the existing secrets, sorted by key
+func Plan(existing []sqlc.Secret, incoming map[string]string, policy ConflictPolicy) ([]Change, error) {
+	current, err := decodeValues(existing)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(incoming))
+	for key := range incoming {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	changes := make([]Change, 0, len(keys))
+	for _, key := range keys {
+		value, exists := current[key]
+		switch {
+		case !exists:
+			changes = append(changes, Change{Key: key, Action: CreateAction})
+		case value == incoming[key]:
+			changes = append(changes, Change{Key: key, Action: UnchangedAction})
+		case policy == OverwritePolicy:
+			changes = append(changes, Change{Key: key, Action: OverwriteAction})
+		case policy == SkipPolicy:
+			changes = append(changes, Change{Key: key, Action: SkipAction})
+		default:
+			return nil, fmt.Errorf("%w: '%s'", ErrConflict, key)
+		}
+	}
+	return changes, nil
+}
+
+// Import plans and applies the changes in a single transaction. A dry run only plans them.
+func Import(ctx context.Context, c storage.Store, incoming map[string]string, policy ConflictPolicy, dryRun bool) ([]Change, error) {
+	var changes []Change
+	err := c.WithTx(ctx, func(q sqlc.Querier) error {
+		var err error
+		changes, err = ImportTx(ctx, q, incoming, policy, dryRun)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ImportTx is Import within the transaction of q, e.g. to save a log entry with the changes
+func ImportTx(ctx context.Context, q sqlc.Querier, incoming map[string]string, policy ConflictPolicy, dryRun bool) ([]Change, error) {
+	existing, err := q.ListSecrets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	changes, err := Plan(existing, incoming, policy)
+	if err != nil || dryRun {
+		return changes, err
+	}
+	for _, change := range changes {
+		switch change.Action {
+		case CreateAction:
+			_, err = q.CreateSecret(ctx, sqlc.CreateSecretParams{
+				ID:    utils.CreateUUID(),
+				Key:   change.Key,
+				Value: utils.B64Encode(incoming[change.Key]),
+			})
+		case OverwriteAction:
+			_, err = q.UpdateSecret(ctx, sqlc.UpdateSecretParams{
+				Key:   change.Key,
+				Value: utils.B64Encode(incoming[change.Key]),
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s secret '%s': %w", change.Action, change.Key, err)
+		}
+	}
+	return changes, nil
+}
+
+// decodeValues maps the keys to the decoded values of the secrets
+func decodeValues(secrets []sqlc.Secret) (map[string]string, error) {
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		value, err := base64.StdEncoding.DecodeString(secret.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode secret '%s': %w", secret.Key, err)
+		}
+		values[secret.Key] = string(value)
+	}
+	return values, nil
+}
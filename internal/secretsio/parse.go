@@ -0,0 +1,139 @@
+package secretsio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+type Format string
+
+const (
+	EnvFormat  Format = "env"
+	JsonFormat Format = "json"
+	YamlFormat Format = "yaml"
+)
+
+// DetectFormat guesses the format from a file name, e.g. ".env", "prod.env", "secrets.yml"
+func DetectFormat(filename string) (Format, error) {
+	base := strings.ToLower(filepath.Base(filename))
+	switch {
+	case base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env"):
+		return EnvFormat, nil
+	case strings.HasSuffix(base, ".json"):
+		return JsonFormat, nil
+	case strings.HasSuffix(base, ".yaml") || strings.HasSuffix(base, ".yml"):
+		return YamlFormat, nil
+	default:
+		return "", fmt.Errorf("can't detect the format of '%s', expected %s, %s or %s", filename, EnvFormat, JsonFormat, YamlFormat)
+	}
+}
+
+// Parse reads secrets from the data. Nested json and yaml objects are flattened to "a/b/c" keys.
+func Parse(format Format, data []byte) (map[string]string, error) {
+	switch format {
+	case EnvFormat:
+		values, err := godotenv.UnmarshalBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env file: %w", err)
+		}
+		return values, nil
+	case JsonFormat:
+		var doc map[string]any
+		// numbers are kept as written, a float64 rounds ids like 12345678901234567890
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("invalid json, expected an object: %w", err)
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			return nil, fmt.Errorf("invalid json, expected a single object")
+		}
+		return flatten(doc)
+	case YamlFormat:
+		var doc map[string]any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid yaml, expected a mapping: %w", err)
+		}
+		return flatten(doc)
+	default:
+		return nil, fmt.Errorf("unknown format '%s', expected %s, %s or %s", format, EnvFormat, JsonFormat, YamlFormat)
+	}
+}
+
+func flatten(doc map[string]any) (map[string]string, error) {
+	values := map[string]string{}
+	if err := flattenInto(values, "", doc); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flattenInto(values map[string]string, prefix string, doc map[string]any) error {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "/" + k
+		}
+		if nested, ok := doc[k].(map[string]any); ok {
+			if err := flattenInto(values, key, nested); err != nil {
+				return err
+			}
+			continue
+		}
+		// e.g. {"a": {"b": 1}, "a/b": 2}
+		if _, ok := values[key]; ok {
+			return fmt.Errorf("key '%s' is set more than once", key)
+		}
+		switch v := doc[k].(type) {
+		case string:
+			values[key] = v
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		case json.Number:
+			values[key] = v.String()
+		case int:
+			values[key] = strconv.Itoa(v)
+		case int64:
+			values[key] = strconv.FormatInt(v, 10)
+		case uint64:
+			values[key] = strconv.FormatUint(v, 10)
+		case float64:
+			values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case time.Time:
+			// unquoted yaml dates
+			values[key] = v.Format(time.RFC3339)
+		case nil:
+			values[key] = ""
+		default:
+			return fmt.Errorf("unsupported value of '%s', expected a string, number, boolean or object", key)
+		}
+	}
+	return nil
+}
+
+// WithPrefix prepends the prefix to every key, e.g. "app/" turns "DB_URL" into "app/DB_URL"
+func WithPrefix(values map[string]string, prefix string) map[string]string {
+	if prefix == "" {
+		return values
+	}
+	prefixed := make(map[string]string, len(values))
+	for key, value := range values {
+		prefixed[prefix+key] = value
+	}
+	return prefixed
+}
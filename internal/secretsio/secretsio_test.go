@@ -0,0 +1,171 @@
+package secretsio_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tomek7667/go-http-helpers/utils"
+	"github.com/tomek7667/secrets/internal/secretsio"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+func TestParse(t *testing.T) {
+	scenarios := map[string]struct {
+		format   secretsio.Format
+		data     string
+		expected map[string]string
+	}{
+		"env": {
+			format:   secretsio.EnvFormat,
+			data:     "# comment\nDB_URL=postgres://localhost\nexport TOKEN=\"a b\"\n",
+			expected: map[string]string{"DB_URL": "postgres://localhost", "TOKEN": "a b"},
+		},
+		"json": {
+			format:   secretsio.JsonFormat,
+			data:     `{"DB_URL": "postgres://localhost", "PORT": 5432, "DEBUG": false}`,
+			expected: map[string]string{"DB_URL": "postgres://localhost", "PORT": "5432", "DEBUG": "false"},
+		},
+		"nested yaml": {
+			format:   secretsio.YamlFormat,
+			data:     "aws:\n  access-key: AKIA\n  prod:\n    secret: s3cr3t\nport: 80\n",
+			expected: map[string]string{"aws/access-key": "AKIA", "aws/prod/secret": "s3cr3t", "port": "80"},
+		},
+		"large json numbers": {
+			format:   secretsio.JsonFormat,
+			data:     `{"id": 12345678901234567890, "ratio": 0.1, "exp": 1e3}`,
+			expected: map[string]string{"id": "12345678901234567890", "ratio": "0.1", "exp": "1e3"},
+		},
+		"large yaml integers": {
+			format:   secretsio.YamlFormat,
+			data:     "int64: 9223372036854775807\nuint64: 12345678901234567890\n",
+			expected: map[string]string{"int64": "9223372036854775807", "uint64": "12345678901234567890"},
+		},
+	}
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			values, err := secretsio.Parse(scenario.format, []byte(scenario.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(values, scenario.expected) {
+				t.Errorf("expected %v, got %v", scenario.expected, values)
+			}
+		})
+	}
+
+	if _, err := secretsio.Parse(secretsio.YamlFormat, []byte("list:\n  - a\n")); err == nil {
+		t.Error("expected lists to be rejected")
+	}
+	if _, err := secretsio.Parse(secretsio.JsonFormat, []byte(`{"a": {"b": "y"}, "a/b": "x"}`)); err == nil {
+		t.Error("expected a nested and a flat key of the same name to be rejected")
+	}
+	if _, err := secretsio.Parse(secretsio.JsonFormat, []byte(`{"a": "x"} {"b": "y"}`)); err == nil {
+		t.Error("expected trailing json to be rejected")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	for filename, expected := range map[string]secretsio.Format{
+		".env":         secretsio.EnvFormat,
+		".env.local":   secretsio.EnvFormat,
+		"prod.env":     secretsio.EnvFormat,
+		"secrets.json": secretsio.JsonFormat,
+		"a/b.YML":      secretsio.YamlFormat,
+	} {
+		format, err := secretsio.DetectFormat(filename)
+		if err != nil || format != expected {
+			t.Errorf("expected %s for '%s', got '%s' (%v)", expected, filename, format, err)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	existing := []sqlc.Secret{
+		{Key: "same", Value: utils.B64Encode("1")},
+		{Key: "changed", Value: utils.B64Encode("old")},
+	}
+	incoming := map[string]string{"same": "1", "changed": "new", "added": "x"}
+
+	changes, err := secretsio.Plan(existing, incoming, secretsio.SkipPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []secretsio.Change{
+		{Key: "added", Action: secretsio.CreateAction},
+		{Key: "changed", Action: secretsio.SkipAction},
+		{Key: "same", Action: secretsio.UnchangedAction},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %v, got %v", expected, changes)
+	}
+
+	changes, err = secretsio.Plan(existing, incoming, secretsio.OverwritePolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes[1].Action != secretsio.OverwriteAction {
+		t.Errorf("expected 'changed' to be overwritten, got %s", changes[1].Action)
+	}
+
+	_, err = secretsio.Plan(existing, incoming, secretsio.FailPolicy)
+	if !errors.Is(err, secretsio.ErrConflict) {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	for key, expected := range map[string]string{
+		"aws/access-key": "AWS_ACCESS_KEY",
+		"DB_URL":         "DB_URL",
+		"1password.key":  "_1PASSWORD_KEY",
+	} {
+		if name := secretsio.EnvName(key); name != expected {
+			t.Errorf("expected %s for '%s', got %s", expected, key, name)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	secrets := []sqlc.Secret{
+		{Key: "app/db-url", Value: utils.B64Encode("postgres://u:p@localhost/db?a=1&b=2")},
+		{Key: "app/quoted", Value: utils.B64Encode("it's a \"$HOME\"\nline")},
+		{Key: "other", Value: utils.B64Encode("left out")},
+	}
+	opts := secretsio.ExportOptions{Prefix: "app/", StripPrefix: true, EnvNames: true}
+	expected := map[string]string{
+		"DB_URL": "postgres://u:p@localhost/db?a=1&b=2",
+		"QUOTED": "it's a \"$HOME\"\nline",
+	}
+	for _, format := range []secretsio.Format{secretsio.EnvFormat, secretsio.JsonFormat, secretsio.YamlFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			data, err := secretsio.Export(format, secrets, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values, err := secretsio.Parse(format, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(values, expected) {
+				t.Errorf("expected %v, got %v", expected, values)
+			}
+		})
+	}
+
+	data, err := secretsio.Export(secretsio.ShellFormat, secrets[:1], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "export DB_URL='postgres://u:p@localhost/db?a=1&b=2'\n" {
+		t.Errorf("unexpected shell export %q", data)
+	}
+
+	if _, err := secretsio.Export(secretsio.EnvFormat, secrets, secretsio.ExportOptions{}); err == nil {
+		t.Error("expected keys that aren't variable names to be rejected")
+	}
+	colliding := append(secrets, sqlc.Secret{Key: "app/db_url", Value: utils.B64Encode("x")})
+	if _, err := secretsio.Export(secretsio.JsonFormat, colliding, opts); err == nil {
+		t.Error("expected colliding names to be rejected")
+	}
+}
@@ -8,6 +8,40 @@ import (
 	"time"
 )
 
+type ClusterNode struct {
+	ID      string `db:"id" json:"id"`
+	Address string `db:"address" json:"address"`
+	Url     string `db:"url" json:"url"`
+}
+
+type ClusterState struct {
+	ID           int64 `db:"id" json:"id"`
+	AppliedIndex int64 `db:"applied_index" json:"applied_index"`
+}
+
+type DynamicRole struct {
+	ID                string     `db:"id" json:"id"`
+	CreatedAt         *time.Time `db:"created_at" json:"created_at"`
+	Name              string     `db:"name" json:"name"`
+	Engine            string     `db:"engine" json:"engine"`
+	Config            string     `db:"config" json:"config"`
+	DefaultTtlSeconds int64      `db:"default_ttl_seconds" json:"default_ttl_seconds"`
+	MaxTtlSeconds     int64      `db:"max_ttl_seconds" json:"max_ttl_seconds"`
+}
+
+type Lease struct {
+	ID        string     `db:"id" json:"id"`
+	CreatedAt *time.Time `db:"created_at" json:"created_at"`
+	RoleID    *string    `db:"role_id" json:"role_id"`
+	SecretKey *string    `db:"secret_key" json:"secret_key"`
+	TokenID   *string    `db:"token_id" json:"token_id"`
+	Username  *string    `db:"username" json:"username"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at" json:"revoked_at"`
+	StaleAt   *time.Time `db:"stale_at" json:"stale_at"`
+	LastError *string    `db:"last_error" json:"last_error"`
+}
+
 type Log struct {
 	ID           string     `db:"id" json:"id"`
 	CreatedAt    *time.Time `db:"created_at" json:"created_at"`
@@ -24,11 +58,43 @@ type Permission struct {
 	SecretKeyPattern string     `db:"secret_key_pattern" json:"secret_key_pattern"`
 }
 
+type RotationPolicy struct {
+	ID              string     `db:"id" json:"id"`
+	CreatedAt       *time.Time `db:"created_at" json:"created_at"`
+	SecretKey       string     `db:"secret_key" json:"secret_key"`
+	IntervalSeconds int64      `db:"interval_seconds" json:"interval_seconds"`
+	Generator       string     `db:"generator" json:"generator"`
+	LastRotatedAt   *time.Time `db:"last_rotated_at" json:"last_rotated_at"`
+	NextRotationAt  time.Time  `db:"next_rotation_at" json:"next_rotation_at"`
+	LastError       *string    `db:"last_error" json:"last_error"`
+}
+
 type Secret struct {
 	ID        string     `db:"id" json:"id"`
 	CreatedAt *time.Time `db:"created_at" json:"created_at"`
 	Key       string     `db:"key" json:"key"`
 	Value     string     `db:"value" json:"value"`
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at"`
+	RotateBy  *time.Time `db:"rotate_by" json:"rotate_by"`
+}
+
+type SecretVersion struct {
+	ID        string     `db:"id" json:"id"`
+	CreatedAt *time.Time `db:"created_at" json:"created_at"`
+	SecretKey string     `db:"secret_key" json:"secret_key"`
+	Version   int64      `db:"version" json:"version"`
+	Value     string     `db:"value" json:"value"`
+}
+
+type Share struct {
+	ID         string     `db:"id" json:"id"`
+	CreatedAt  *time.Time `db:"created_at" json:"created_at"`
+	CreatedBy  string     `db:"created_by" json:"created_by"`
+	Ciphertext string     `db:"ciphertext" json:"ciphertext"`
+	Nonce      string     `db:"nonce" json:"nonce"`
+	MaxViews   int64      `db:"max_views" json:"max_views"`
+	Views      int64      `db:"views" json:"views"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
 }
 
 type Token struct {
@@ -44,3 +110,27 @@ type User struct {
 	Username  string     `db:"username" json:"username"`
 	Password  string     `db:"password" json:"password"`
 }
+
+type Webhook struct {
+	ID            string     `db:"id" json:"id"`
+	CreatedAt     *time.Time `db:"created_at" json:"created_at"`
+	Url           string     `db:"url" json:"url"`
+	KeyPattern    string     `db:"key_pattern" json:"key_pattern"`
+	Events        string     `db:"events" json:"events"`
+	SigningSecret string     `db:"signing_secret" json:"signing_secret"`
+}
+
+type WebhookDelivery struct {
+	ID             string     `db:"id" json:"id"`
+	CreatedAt      *time.Time `db:"created_at" json:"created_at"`
+	WebhookID      string     `db:"webhook_id" json:"webhook_id"`
+	Event          string     `db:"event" json:"event"`
+	SecretKey      string     `db:"secret_key" json:"secret_key"`
+	Payload        string     `db:"payload" json:"payload"`
+	Status         string     `db:"status" json:"status"`
+	Attempts       int64      `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	LastStatusCode *int64     `db:"last_status_code" json:"last_status_code"`
+	LastError      *string    `db:"last_error" json:"last_error"`
+	DeliveredAt    *time.Time `db:"delivered_at" json:"delivered_at"`
+}
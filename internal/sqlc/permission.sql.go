@@ -85,6 +85,49 @@ func (q *Queries) GetPermission(ctx context.Context, id string) (Permission, err
 	return i, err
 }
 
+const listOrphanedPermissions = `-- name: ListOrphanedPermissions :many
+SELECT permission.id, permission.created_at, permission.token_id, permission.secret_key_pattern
+FROM permission
+LEFT JOIN token ON token.id = permission.token_id
+WHERE token.id IS NULL
+ORDER BY permission.created_at DESC
+`
+
+// ListOrphanedPermissions
+//
+//	SELECT permission.id, permission.created_at, permission.token_id, permission.secret_key_pattern
+//	FROM permission
+//	LEFT JOIN token ON token.id = permission.token_id
+//	WHERE token.id IS NULL
+//	ORDER BY permission.created_at DESC
+func (q *Queries) ListOrphanedPermissions(ctx context.Context) ([]Permission, error) {
+	rows, err := q.db.QueryContext(ctx, listOrphanedPermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Permission{}
+	for rows.Next() {
+		var i Permission
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.TokenID,
+			&i.SecretKeyPattern,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listPermissions = `-- name: ListPermissions :many
 SELECT id, created_at, token_id, secret_key_pattern
 FROM permission
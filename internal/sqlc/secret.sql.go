@@ -7,23 +7,44 @@ package sqlc
 
 import (
 	"context"
+	"time"
 )
 
+const countSecrets = `-- name: CountSecrets :one
+SELECT COUNT(*)
+FROM secret
+`
+
+// CountSecrets
+//
+//	SELECT COUNT(*)
+//	FROM secret
+func (q *Queries) CountSecrets(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSecrets)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createSecret = `-- name: CreateSecret :one
 INSERT INTO secret (
     id,
     key,
-    value
+    value,
+    expires_at,
+    rotate_by
 ) VALUES (
-    ?, ?, ?
+    ?, ?, ?, ?, ?
 )
-RETURNING id, created_at, "key", value
+RETURNING id, created_at, "key", value, expires_at, rotate_by
 `
 
 type CreateSecretParams struct {
-	ID    string `db:"id" json:"id"`
-	Key   string `db:"key" json:"key"`
-	Value string `db:"value" json:"value"`
+	ID        string     `db:"id" json:"id"`
+	Key       string     `db:"key" json:"key"`
+	Value     string     `db:"value" json:"value"`
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at"`
+	RotateBy  *time.Time `db:"rotate_by" json:"rotate_by"`
 }
 
 // CreateSecret
@@ -31,19 +52,29 @@ type CreateSecretParams struct {
 //	INSERT INTO secret (
 //	    id,
 //	    key,
-//	    value
+//	    value,
+//	    expires_at,
+//	    rotate_by
 //	) VALUES (
-//	    ?, ?, ?
+//	    ?, ?, ?, ?, ?
 //	)
-//	RETURNING id, created_at, "key", value
+//	RETURNING id, created_at, "key", value, expires_at, rotate_by
 func (q *Queries) CreateSecret(ctx context.Context, arg CreateSecretParams) (Secret, error) {
-	row := q.db.QueryRowContext(ctx, createSecret, arg.ID, arg.Key, arg.Value)
+	row := q.db.QueryRowContext(ctx, createSecret,
+		arg.ID,
+		arg.Key,
+		arg.Value,
+		arg.ExpiresAt,
+		arg.RotateBy,
+	)
 	var i Secret
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.Key,
 		&i.Value,
+		&i.ExpiresAt,
+		&i.RotateBy,
 	)
 	return i, err
 }
@@ -63,14 +94,14 @@ func (q *Queries) DeleteSecret(ctx context.Context, key string) error {
 }
 
 const getSecret = `-- name: GetSecret :one
-SELECT id, created_at, "key", value
+SELECT id, created_at, "key", value, expires_at, rotate_by
 FROM secret
 WHERE key = ?
 `
 
 // GetSecret
 //
-//	SELECT id, created_at, "key", value
+//	SELECT id, created_at, "key", value, expires_at, rotate_by
 //	FROM secret
 //	WHERE key = ?
 func (q *Queries) GetSecret(ctx context.Context, key string) (Secret, error) {
@@ -81,19 +112,72 @@ func (q *Queries) GetSecret(ctx context.Context, key string) (Secret, error) {
 		&i.CreatedAt,
 		&i.Key,
 		&i.Value,
+		&i.ExpiresAt,
+		&i.RotateBy,
 	)
 	return i, err
 }
 
+const listExpiringSecrets = `-- name: ListExpiringSecrets :many
+SELECT id, created_at, "key", value, expires_at, rotate_by
+FROM secret
+WHERE (expires_at IS NOT NULL AND expires_at <= ?1)
+    OR (rotate_by IS NOT NULL AND rotate_by <= ?1)
+ORDER BY CASE
+    WHEN rotate_by IS NULL OR expires_at < rotate_by THEN COALESCE(expires_at, rotate_by)
+    ELSE rotate_by
+END
+`
+
+// ListExpiringSecrets
+//
+//	SELECT id, created_at, "key", value, expires_at, rotate_by
+//	FROM secret
+//	WHERE (expires_at IS NOT NULL AND expires_at <= ?1)
+//	    OR (rotate_by IS NOT NULL AND rotate_by <= ?1)
+//	ORDER BY CASE
+//	    WHEN rotate_by IS NULL OR expires_at < rotate_by THEN COALESCE(expires_at, rotate_by)
+//	    ELSE rotate_by
+//	END
+func (q *Queries) ListExpiringSecrets(ctx context.Context, before *time.Time) ([]Secret, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiringSecrets, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Secret{}
+	for rows.Next() {
+		var i Secret
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Key,
+			&i.Value,
+			&i.ExpiresAt,
+			&i.RotateBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSecrets = `-- name: ListSecrets :many
-SELECT id, created_at, "key", value
+SELECT id, created_at, "key", value, expires_at, rotate_by
 FROM secret
 ORDER BY created_at DESC
 `
 
 // ListSecrets
 //
-//	SELECT id, created_at, "key", value
+//	SELECT id, created_at, "key", value, expires_at, rotate_by
 //	FROM secret
 //	ORDER BY created_at DESC
 func (q *Queries) ListSecrets(ctx context.Context) ([]Secret, error) {
@@ -110,6 +194,51 @@ func (q *Queries) ListSecrets(ctx context.Context) ([]Secret, error) {
 			&i.CreatedAt,
 			&i.Key,
 			&i.Value,
+			&i.ExpiresAt,
+			&i.RotateBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSecretsByKeyPattern = `-- name: ListSecretsByKeyPattern :many
+SELECT id, created_at, "key", value, expires_at, rotate_by
+FROM secret
+WHERE key LIKE ?1 ESCAPE '\'
+ORDER BY created_at DESC
+`
+
+// ListSecretsByKeyPattern
+//
+//	SELECT id, created_at, "key", value, expires_at, rotate_by
+//	FROM secret
+//	WHERE key LIKE ?1 ESCAPE '\'
+//	ORDER BY created_at DESC
+func (q *Queries) ListSecretsByKeyPattern(ctx context.Context, pattern string) ([]Secret, error) {
+	rows, err := q.db.QueryContext(ctx, listSecretsByKeyPattern, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Secret{}
+	for rows.Next() {
+		var i Secret
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Key,
+			&i.Value,
+			&i.ExpiresAt,
+			&i.RotateBy,
 		); err != nil {
 			return nil, err
 		}
@@ -129,7 +258,7 @@ UPDATE secret
 SET
     value = ?
 WHERE key = ?
-RETURNING id, created_at, "key", value
+RETURNING id, created_at, "key", value, expires_at, rotate_by
 `
 
 type UpdateSecretParams struct {
@@ -143,7 +272,7 @@ type UpdateSecretParams struct {
 //	SET
 //	    value = ?
 //	WHERE key = ?
-//	RETURNING id, created_at, "key", value
+//	RETURNING id, created_at, "key", value, expires_at, rotate_by
 func (q *Queries) UpdateSecret(ctx context.Context, arg UpdateSecretParams) (Secret, error) {
 	row := q.db.QueryRowContext(ctx, updateSecret, arg.Value, arg.Key)
 	var i Secret
@@ -152,6 +281,45 @@ func (q *Queries) UpdateSecret(ctx context.Context, arg UpdateSecretParams) (Sec
 		&i.CreatedAt,
 		&i.Key,
 		&i.Value,
+		&i.ExpiresAt,
+		&i.RotateBy,
+	)
+	return i, err
+}
+
+const updateSecretDates = `-- name: UpdateSecretDates :one
+UPDATE secret
+SET
+    expires_at = ?,
+    rotate_by = ?
+WHERE key = ?
+RETURNING id, created_at, "key", value, expires_at, rotate_by
+`
+
+type UpdateSecretDatesParams struct {
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at"`
+	RotateBy  *time.Time `db:"rotate_by" json:"rotate_by"`
+	Key       string     `db:"key" json:"key"`
+}
+
+// UpdateSecretDates
+//
+//	UPDATE secret
+//	SET
+//	    expires_at = ?,
+//	    rotate_by = ?
+//	WHERE key = ?
+//	RETURNING id, created_at, "key", value, expires_at, rotate_by
+func (q *Queries) UpdateSecretDates(ctx context.Context, arg UpdateSecretDatesParams) (Secret, error) {
+	row := q.db.QueryRowContext(ctx, updateSecretDates, arg.ExpiresAt, arg.RotateBy, arg.Key)
+	var i Secret
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Key,
+		&i.Value,
+		&i.ExpiresAt,
+		&i.RotateBy,
 	)
 	return i, err
 }
@@ -0,0 +1,160 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: secretversion.sql
+
+package sqlc
+
+import (
+	"context"
+)
+
+const createSecretVersion = `-- name: CreateSecretVersion :one
+INSERT INTO secret_version (
+    id,
+    secret_key,
+    version,
+    value
+) VALUES (
+    ?, ?, ?, ?
+)
+RETURNING id, created_at, secret_key, version, value
+`
+
+type CreateSecretVersionParams struct {
+	ID        string `db:"id" json:"id"`
+	SecretKey string `db:"secret_key" json:"secret_key"`
+	Version   int64  `db:"version" json:"version"`
+	Value     string `db:"value" json:"value"`
+}
+
+// CreateSecretVersion
+//
+//	INSERT INTO secret_version (
+//	    id,
+//	    secret_key,
+//	    version,
+//	    value
+//	) VALUES (
+//	    ?, ?, ?, ?
+//	)
+//	RETURNING id, created_at, secret_key, version, value
+func (q *Queries) CreateSecretVersion(ctx context.Context, arg CreateSecretVersionParams) (SecretVersion, error) {
+	row := q.db.QueryRowContext(ctx, createSecretVersion,
+		arg.ID,
+		arg.SecretKey,
+		arg.Version,
+		arg.Value,
+	)
+	var i SecretVersion
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.SecretKey,
+		&i.Version,
+		&i.Value,
+	)
+	return i, err
+}
+
+const deleteSecretVersions = `-- name: DeleteSecretVersions :exec
+DELETE FROM secret_version
+WHERE secret_key = ?
+`
+
+// DeleteSecretVersions
+//
+//	DELETE FROM secret_version
+//	WHERE secret_key = ?
+func (q *Queries) DeleteSecretVersions(ctx context.Context, secretKey string) error {
+	_, err := q.db.ExecContext(ctx, deleteSecretVersions, secretKey)
+	return err
+}
+
+const getLatestSecretVersion = `-- name: GetLatestSecretVersion :one
+SELECT CAST(COALESCE(MAX(version), 0) AS INTEGER) AS version
+FROM secret_version
+WHERE secret_key = ?
+`
+
+// GetLatestSecretVersion
+//
+//	SELECT CAST(COALESCE(MAX(version), 0) AS INTEGER) AS version
+//	FROM secret_version
+//	WHERE secret_key = ?
+func (q *Queries) GetLatestSecretVersion(ctx context.Context, secretKey string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSecretVersion, secretKey)
+	var version int64
+	err := row.Scan(&version)
+	return version, err
+}
+
+const getNewestSecretVersion = `-- name: GetNewestSecretVersion :one
+SELECT id, created_at, secret_key, version, value
+FROM secret_version
+WHERE secret_key = ?
+ORDER BY version DESC
+LIMIT 1
+`
+
+// GetNewestSecretVersion
+//
+//	SELECT id, created_at, secret_key, version, value
+//	FROM secret_version
+//	WHERE secret_key = ?
+//	ORDER BY version DESC
+//	LIMIT 1
+func (q *Queries) GetNewestSecretVersion(ctx context.Context, secretKey string) (SecretVersion, error) {
+	row := q.db.QueryRowContext(ctx, getNewestSecretVersion, secretKey)
+	var i SecretVersion
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.SecretKey,
+		&i.Version,
+		&i.Value,
+	)
+	return i, err
+}
+
+const listSecretVersions = `-- name: ListSecretVersions :many
+SELECT id, created_at, secret_key, version, value
+FROM secret_version
+WHERE secret_key = ?
+ORDER BY version DESC
+`
+
+// ListSecretVersions
+//
+//	SELECT id, created_at, secret_key, version, value
+//	FROM secret_version
+//	WHERE secret_key = ?
+//	ORDER BY version DESC
+func (q *Queries) ListSecretVersions(ctx context.Context, secretKey string) ([]SecretVersion, error) {
+	rows, err := q.db.QueryContext(ctx, listSecretVersions, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SecretVersion{}
+	for rows.Next() {
+		var i SecretVersion
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.SecretKey,
+			&i.Version,
+			&i.Value,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -10,6 +10,22 @@ import (
 	"time"
 )
 
+const countTokens = `-- name: CountTokens :one
+SELECT COUNT(*)
+FROM token
+`
+
+// CountTokens
+//
+//	SELECT COUNT(*)
+//	FROM token
+func (q *Queries) CountTokens(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTokens)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createToken = `-- name: CreateToken :one
 INSERT INTO token (
     id,
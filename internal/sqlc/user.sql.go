@@ -10,7 +10,7 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO user (
+INSERT INTO "user" (
     id,
     username,
     password
@@ -28,7 +28,7 @@ type CreateUserParams struct {
 
 // CreateUser
 //
-//	INSERT INTO user (
+//	INSERT INTO "user" (
 //	    id,
 //	    username,
 //	    password
@@ -49,13 +49,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 }
 
 const deleteUser = `-- name: DeleteUser :exec
-DELETE FROM user
+DELETE FROM "user"
 WHERE id = ?
 `
 
 // DeleteUser
 //
-//	DELETE FROM user
+//	DELETE FROM "user"
 //	WHERE id = ?
 func (q *Queries) DeleteUser(ctx context.Context, id string) error {
 	_, err := q.db.ExecContext(ctx, deleteUser, id)
@@ -64,14 +64,14 @@ func (q *Queries) DeleteUser(ctx context.Context, id string) error {
 
 const getUser = `-- name: GetUser :one
 SELECT id, created_at, username, password
-FROM user
+FROM "user"
 WHERE id = ?
 `
 
 // GetUser
 //
 //	SELECT id, created_at, username, password
-//	FROM user
+//	FROM "user"
 //	WHERE id = ?
 func (q *Queries) GetUser(ctx context.Context, id string) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUser, id)
@@ -87,14 +87,14 @@ func (q *Queries) GetUser(ctx context.Context, id string) (User, error) {
 
 const getUserByUsername = `-- name: GetUserByUsername :one
 SELECT id, created_at, username, password
-FROM user
+FROM "user"
 WHERE username = ?
 `
 
 // GetUserByUsername
 //
 //	SELECT id, created_at, username, password
-//	FROM user
+//	FROM "user"
 //	WHERE username = ?
 func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUserByUsername, username)
@@ -110,14 +110,14 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 
 const listUsers = `-- name: ListUsers :many
 SELECT id, created_at, username, password
-FROM user
+FROM "user"
 ORDER BY created_at DESC
 `
 
 // ListUsers
 //
 //	SELECT id, created_at, username, password
-//	FROM user
+//	FROM "user"
 //	ORDER BY created_at DESC
 func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 	rows, err := q.db.QueryContext(ctx, listUsers)
@@ -148,7 +148,7 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 }
 
 const updateUser = `-- name: UpdateUser :one
-UPDATE user
+UPDATE "user"
 SET
     password = ?
 WHERE id = ?
@@ -162,7 +162,7 @@ type UpdateUserParams struct {
 
 // UpdateUser
 //
-//	UPDATE user
+//	UPDATE "user"
 //	SET
 //	    password = ?
 //	WHERE id = ?
@@ -3,57 +3,143 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
-
-	_ "embed"
-
-	_ "github.com/mattn/go-sqlite3"
+	"log/slog"
+	"sync/atomic"
 
+	"github.com/tomek7667/secrets/internal/metrics"
 	"github.com/tomek7667/secrets/internal/sqlc"
+	"github.com/tomek7667/secrets/internal/tracing"
 )
 
-//go:embed secrets.sqlite
-var noGoose []byte
-
 type Client struct {
 	Path string
 
-	DB      *sql.DB
-	Queries *sqlc.Queries
+	// DB is the single writer connection, which migrations and transactions use too
+	DB *sql.DB
+	// ReadDB is the read-only pool the SELECT queries go to, WAL lets it read while DB writes
+	ReadDB *sql.DB
+	*sqlc.Queries
+
+	pragmas []string
+	replica atomic.Bool
 }
 
-func New(ctx context.Context, dbPath string) (*Client, error) {
-	c := &Client{
-		Path: dbPath,
+// New opens the database, creating it when it doesn't exist, and applies the pending migrations
+func New(ctx context.Context, dbPath string, opts Options) (*Client, error) {
+	c, err := Open(ctx, dbPath, opts)
+	if err != nil {
+		return nil, err
 	}
-	if !c.dbExists() {
-		err := c.writeDb()
-		if err != nil {
-			return nil, fmt.Errorf("failed to write default db: %w", err)
-		}
+	results, err := c.Migrate(ctx)
+	if err != nil {
+		c.Close()
+		return nil, err
 	}
-	db, err := sql.Open("sqlite3", c.Path)
+	for _, result := range results {
+		slog.Info("applied migration", "version", result.Source.Version, "duration", result.Duration)
+	}
+	return c, nil
+}
+
+// Open opens the database as it is, without migrating it
+func Open(ctx context.Context, dbPath string, opts Options) (*Client, error) {
+	return open(ctx, dbPath, opts, false)
+}
+
+// OpenReplica opens a database the replication follower writes page by page, see replication.Follower.
+// It stays in the rollback journal mode, so that readers notice the pages changing, and read only until Promote.
+func OpenReplica(ctx context.Context, dbPath string, opts Options) (*Client, error) {
+	return open(ctx, dbPath, opts, true)
+}
+
+func open(ctx context.Context, dbPath string, opts Options, replica bool) (*Client, error) {
+	pragmas, err := opts.pragmas()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open sqlite db: %w", err)
+		return nil, err
 	}
-	if err := db.PingContext(ctx); err != nil {
+	c := &Client{Path: dbPath, pragmas: pragmas}
+	c.replica.Store(replica)
+	// a single writer queues writes in go instead of failing them with "database is locked",
+	// and immediate transactions take the write lock upfront instead of failing to upgrade it
+	writer := sql.OpenDB(newConnector(fmt.Sprintf("file:%s?_txlock=immediate", dbPath), func() []string {
+		if c.replica.Load() {
+			return readPragmas(pragmas)
+		}
+		return pragmas
+	}))
+	writer.SetMaxOpenConns(1)
+	if err := writer.PingContext(ctx); err != nil {
+		writer.Close()
 		return nil, fmt.Errorf("failed to ping sqlite db: %w", err)
 	}
-	c.DB = db
-	c.Queries = sqlc.New(db)
+	reader := sql.OpenDB(newConnector(fmt.Sprintf("file:%s", dbPath), func() []string {
+		return readPragmas(pragmas)
+	}))
+	reader.SetMaxOpenConns(opts.readConns())
+	reader.SetMaxIdleConns(opts.readConns())
+	if err := reader.PingContext(ctx); err != nil {
+		writer.Close()
+		reader.Close()
+		return nil, fmt.Errorf("failed to ping sqlite db: %w", err)
+	}
+	c.DB = writer
+	c.ReadDB = reader
+	c.Queries = sqlc.New(tracing.DB(metrics.DB(routeDB{writer: writer, reader: reader}), "sqlite"))
 	return c, nil
 }
 
-func (c *Client) dbExists() bool {
-	_, err := os.Stat(c.Path)
-	return os.IsExist(err) || err == nil
+// Promote makes a replica writable, switching it to the WAL mode and applying the pending migrations
+func (c *Client) Promote(ctx context.Context) error {
+	if !c.replica.Load() {
+		return fmt.Errorf("%s is not a replica", c.Path)
+	}
+	// the writer has a single connection, so that holding it makes sure no statement runs read only anymore
+	conn, err := c.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the writer connection: %w", err)
+	}
+	c.replica.Store(false)
+	for _, pragma := range append([]string{"query_only=OFF"}, c.pragmas...) {
+		if _, err := conn.ExecContext(ctx, "PRAGMA "+pragma); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to set pragma %s: %w", pragma, err)
+		}
+	}
+	conn.Close()
+	results, err := c.Migrate(ctx)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		slog.Info("applied migration", "version", result.Source.Version, "duration", result.Duration)
+	}
+	return nil
+}
+
+// Replica tells whether the client is a replica that hasn't been promoted
+func (c *Client) Replica() bool {
+	return c.replica.Load()
 }
 
-func (c *Client) writeDb() error {
-	err := os.WriteFile(c.Path, noGoose, 0644)
+// WithTx runs fn in a transaction, which is rolled back when fn returns an error
+func (c *Client) WithTx(ctx context.Context, fn func(q sqlc.Querier) error) error {
+	tx, err := c.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create default db: %w", err)
+		return fmt.Errorf("failed to begin a transaction: %w", err)
+	}
+	defer tx.Rollback()
+	// not c.Queries.WithTx, which would skip the timing and the tracing
+	if err := fn(sqlc.New(tracing.DB(metrics.DB(tx), "sqlite"))); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
 	}
 	return nil
 }
+
+func (c *Client) Close() error {
+	return errors.Join(c.ReadDB.Close(), c.DB.Close())
+}
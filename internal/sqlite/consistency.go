@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// ForeignKeyViolation is a row referencing a missing parent row
+type ForeignKeyViolation struct {
+	Table  string `json:"table"`
+	RowID  int64  `json:"rowid"`
+	Parent string `json:"parent"`
+}
+
+// ForeignKeyViolations lists the rows that break a foreign key, e.g. written while foreign keys were off
+func (c *Client) ForeignKeyViolations(ctx context.Context) ([]ForeignKeyViolation, error) {
+	rows, err := c.ReadDB.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	defer rows.Close()
+	violations := []ForeignKeyViolation{}
+	for rows.Next() {
+		var v ForeignKeyViolation
+		var fkid int64
+		if err := rows.Scan(&v.Table, &v.RowID, &v.Parent, &fkid); err != nil {
+			return nil, err
+		}
+		violations = append(violations, v)
+	}
+	return violations, rows.Err()
+}
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/tomek7667/secrets/schema"
+)
+
+const (
+	migrationLockPollInterval = 100 * time.Millisecond
+	migrationLockTimeout      = time.Minute
+)
+
+// fileLocker keeps concurrent starts from migrating the same database at once, sqlite has no advisory locks
+type fileLocker struct {
+	path string
+	f    *os.File
+}
+
+func (l *fileLocker) Lock(ctx context.Context, _ *sql.DB) error {
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open the migration lock: %w", err)
+	}
+	timeout := time.After(migrationLockTimeout)
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		if locked {
+			l.f = f
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return ctx.Err()
+		case <-timeout:
+			f.Close()
+			return fmt.Errorf("timed out waiting for the migration lock '%s', is another process migrating?", l.path)
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+}
+
+func (l *fileLocker) Unlock(_ context.Context, _ *sql.DB) error {
+	if l.f == nil {
+		return nil
+	}
+	defer l.f.Close()
+	err := unlockFile(l.f)
+	l.f = nil
+	return err
+}
+
+func (c *Client) migrationProvider() (*goose.Provider, error) {
+	return goose.NewProvider(goose.DialectSQLite3, c.DB, schema.Migrations,
+		goose.WithLocker(&fileLocker{path: c.Path + ".migrate.lock"}),
+	)
+}
+
+// Migrate applies the pending migrations. It refuses a database migrated by a newer version,
+// which the queries of this version can't be trusted with.
+func (c *Client) Migrate(ctx context.Context) ([]*goose.MigrationResult, error) {
+	p, err := c.migrationProvider()
+	if err != nil {
+		return nil, err
+	}
+	current, latest, err := p.GetVersions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the schema version: %w", err)
+	}
+	if current > latest {
+		return nil, fmt.Errorf("database schema version %d is newer than the latest known version %d, upgrade secretsserver", current, latest)
+	}
+	results, err := p.Up(ctx)
+	if err != nil {
+		return results, fmt.Errorf("failed to migrate: %w", err)
+	}
+	return results, nil
+}
+
+// MigrateDown rolls back the last applied migration
+func (c *Client) MigrateDown(ctx context.Context) (*goose.MigrationResult, error) {
+	p, err := c.migrationProvider()
+	if err != nil {
+		return nil, err
+	}
+	result, err := p.Down(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to roll back: %w", err)
+	}
+	return result, nil
+}
+
+func (c *Client) MigrationStatus(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	p, err := c.migrationProvider()
+	if err != nil {
+		return nil, err
+	}
+	return p.Status(ctx)
+}
@@ -0,0 +1,99 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/tomek7667/secrets/internal/sqlite"
+	"github.com/tomek7667/secrets/schema"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secrets.sqlite")
+	c, err := sqlite.New(ctx, path, sqlite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Queries.ListSecrets(ctx); err != nil {
+		t.Errorf("expected a migrated database, got %v", err)
+	}
+	statuses, err := c.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := statuses[len(statuses)-1].Source.Version
+	if _, err := c.DB.ExecContext(ctx, "INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, 1)", latest+1); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	_, err = sqlite.New(ctx, path, sqlite.Options{})
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected a newer schema to be refused, got %v", err)
+	}
+}
+
+func TestPermissionForeignKeyMigration(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secrets.sqlite")
+	// a db of before the foreign keys, which let the rows of deleted parents stay
+	c, err := sqlite.Open(ctx, path, sqlite.Options{Pragmas: []string{"foreign_keys=OFF"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, c.DB, schema.Migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.UpTo(ctx, 11); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"INSERT INTO token (id, token) VALUES ('t1', 'token')",
+		"INSERT INTO permission (id, token_id, secret_key_pattern) VALUES ('kept', 't1', 'app/*'), ('orphaned', 'deleted', 'app/*')",
+		"INSERT INTO webhook (id, url, key_pattern, events, signing_secret) VALUES ('w1', 'http://localhost', '*', '*', 'key')",
+		`INSERT INTO webhook_delivery (id, webhook_id, event, secret_key, payload, next_attempt_at)
+		VALUES ('kept', 'w1', 'secret.updated', 'app/db', '{}', CURRENT_TIMESTAMP), ('orphaned', 'deleted', 'secret.updated', 'app/db', '{}', CURRENT_TIMESTAMP)`,
+		"INSERT INTO lease (id, role_id, expires_at) VALUES ('kept', NULL, CURRENT_TIMESTAMP), ('orphaned', 'deleted', CURRENT_TIMESTAMP)",
+	} {
+		if _, err := c.DB.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Close()
+
+	c, err = sqlite.New(ctx, path, sqlite.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for _, table := range []string{"permission", "webhook_delivery", "lease"} {
+		var ids []string
+		rows, err := c.DB.QueryContext(ctx, "SELECT id FROM "+table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if len(ids) != 1 || ids[0] != "kept" {
+			t.Errorf("expected only the row with a parent to be kept in %s, got %v", table, ids)
+		}
+	}
+	violations, err := c.ForeignKeyViolations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("expected no foreign key violations after the migration, got %+v", violations)
+	}
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	defaultBusyTimeout = 5 * time.Second
+	defaultReadConns   = 4
+)
+
+var pragmaPattern = regexp.MustCompile(`^([a-z_]+)\s*=\s*([A-Za-z0-9_-]+)$`)
+
+type Options struct {
+	// BusyTimeout is how long a statement waits for a lock held by another connection, e.g. the
+	// `secretsserver import` of another process, before failing with "database is locked". 5s by default.
+	BusyTimeout time.Duration
+	// ReadConns limits the pool serving SELECT queries, 4 by default. Writes go through a single connection.
+	ReadConns int
+	// Pragmas run on every connection after the defaults, e.g. "synchronous=FULL" or "cache_size=-64000"
+	Pragmas []string
+}
+
+// pragmas are the statements run on every writer connection, the defaults first
+func (o Options) pragmas() ([]string, error) {
+	busyTimeout := o.BusyTimeout
+	if busyTimeout <= 0 {
+		busyTimeout = defaultBusyTimeout
+	}
+	pragmas := []string{
+		// readers don't block the writer and the writer doesn't block readers
+		"journal_mode=WAL",
+		// durable in WAL mode except for the last transactions on a power loss
+		"synchronous=NORMAL",
+		// foreign keys are off by default in sqlite, so that ON DELETE CASCADE would never fire
+		"foreign_keys=ON",
+		fmt.Sprintf("busy_timeout=%d", busyTimeout.Milliseconds()),
+	}
+	for _, pragma := range o.Pragmas {
+		if !pragmaPattern.MatchString(strings.TrimSpace(pragma)) {
+			return nil, fmt.Errorf("invalid pragma '%s', expected name=value, e.g. synchronous=FULL", pragma)
+		}
+		pragmas = append(pragmas, strings.TrimSpace(pragma))
+	}
+	return pragmas, nil
+}
+
+// readPragmas skip journal_mode, which is a property of the database file the writer sets
+func readPragmas(pragmas []string) []string {
+	read := []string{"query_only=ON"}
+	for _, pragma := range pragmas {
+		if name := pragmaPattern.FindStringSubmatch(pragma)[1]; name != "journal_mode" && name != "query_only" {
+			read = append(read, pragma)
+		}
+	}
+	return read
+}
+
+func (o Options) readConns() int {
+	if o.ReadConns <= 0 {
+		return defaultReadConns
+	}
+	return o.ReadConns
+}
+
+// connector opens connections running the pragmas, which the dsn of go-sqlite3 can't express all of
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+// newConnector runs the pragmas returned for each new connection, which change when a replica is promoted
+func newConnector(dsn string, pragmas func() []string) connector {
+	return connector{
+		dsn: dsn,
+		driver: &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				for _, pragma := range pragmas() {
+					if _, err := conn.Exec("PRAGMA "+pragma, nil); err != nil {
+						return fmt.Errorf("failed to set pragma %s: %w", pragma, err)
+					}
+				}
+				return nil
+			},
+		},
+	}
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// routeDB sends SELECT queries to the reader pool and everything else to the writer
+type routeDB struct {
+	writer *sql.DB
+	reader *sql.DB
+}
+
+func (r routeDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.writer.ExecContext(ctx, query, args...)
+}
+
+func (r routeDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.pick(query).PrepareContext(ctx, query)
+}
+
+func (r routeDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.pick(query).QueryContext(ctx, query, args...)
+}
+
+func (r routeDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.pick(query).QueryRowContext(ctx, query, args...)
+}
+
+func (r routeDB) pick(query string) *sql.DB {
+	if isSelect(query) {
+		return r.reader
+	}
+	return r.writer
+}
+
+// selects caches isSelect, the queries are the constants of the sqlc package
+var selects sync.Map
+
+// isSelect tells whether the first statement after the comments is a SELECT, INSERT ... RETURNING is not
+func isSelect(query string) bool {
+	if cached, ok := selects.Load(query); ok {
+		return cached.(bool)
+	}
+	rest := query
+	for {
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "--") {
+			break
+		}
+		_, rest, _ = strings.Cut(rest, "\n")
+	}
+	fields := strings.Fields(rest)
+	result := len(fields) > 0 && strings.EqualFold(fields[0], "SELECT")
+	selects.Store(query, result)
+	return result
+}
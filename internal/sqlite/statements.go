@@ -0,0 +1,259 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+// Statement is a write recorded by Record, which ExecStatements repeats, e.g. on the nodes of a cluster
+type Statement struct {
+	Query string  `json:"query"`
+	Args  []Value `json:"args"`
+}
+
+// Value is an argument of a statement, which keeps its type through json. All fields are nil for NULL.
+type Value struct {
+	Int   *int64     `json:"int,omitempty"`
+	Float *float64   `json:"float,omitempty"`
+	Bool  *bool      `json:"bool,omitempty"`
+	Text  *string    `json:"text,omitempty"`
+	Bytes *[]byte    `json:"bytes,omitempty"`
+	Time  *time.Time `json:"time,omitempty"`
+}
+
+// NewStatement converts the args the way database/sql does before they reach the driver
+func NewStatement(query string, args ...any) (Statement, error) {
+	statement := Statement{Query: query, Args: make([]Value, len(args))}
+	for i, arg := range args {
+		v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return Statement{}, fmt.Errorf("unsupported argument %d of %q: %w", i+1, query, err)
+		}
+		switch v := v.(type) {
+		case nil:
+		case int64:
+			statement.Args[i].Int = &v
+		case float64:
+			statement.Args[i].Float = &v
+		case bool:
+			statement.Args[i].Bool = &v
+		case string:
+			statement.Args[i].Text = &v
+		case []byte:
+			statement.Args[i].Bytes = &v
+		case time.Time:
+			statement.Args[i].Time = &v
+		default:
+			return Statement{}, fmt.Errorf("unsupported argument %d of %q: %T", i+1, query, v)
+		}
+	}
+	return statement, nil
+}
+
+func (s Statement) args() []any {
+	args := make([]any, len(s.Args))
+	for i, v := range s.Args {
+		switch {
+		case v.Int != nil:
+			args[i] = *v.Int
+		case v.Float != nil:
+			args[i] = *v.Float
+		case v.Bool != nil:
+			args[i] = *v.Bool
+		case v.Text != nil:
+			args[i] = *v.Text
+		case v.Bytes != nil:
+			args[i] = *v.Bytes
+		case v.Time != nil:
+			args[i] = *v.Time
+		}
+	}
+	return args
+}
+
+// Record runs fn in a transaction, which is rolled back, and returns the writes fn made.
+// fn reads the database as it is, so that the writes repeat the same only on the same state.
+// The created_at the inserts leave to DEFAULT CURRENT_TIMESTAMP is set by the recording instead,
+// so that a write repeated later stores the same time, and fn sees the rows as they are repeated.
+func (c *Client) Record(ctx context.Context, fn func(q sqlc.Querier) error) ([]Statement, error) {
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin a transaction: %w", err)
+	}
+	defer tx.Rollback()
+	r := &recorder{tx: tx, now: time.Now().UTC().Format(time.DateTime)}
+	err = fn(sqlc.New(r))
+	if r.err != nil {
+		return nil, r.err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.statements, nil
+}
+
+// ExecStatements runs the statements in a transaction, none of them is applied when one fails
+func (c *Client) ExecStatements(ctx context.Context, statements []Statement) error {
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin a transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement.Query, statement.args()...); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// Replace overwrites the database with the one at path using the backup api, so that the open connections read the new content
+func (c *Client) Replace(ctx context.Context, path string) error {
+	src, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer srcConn.Close()
+	destConn, err := c.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the writer connection: %w", err)
+	}
+	defer destConn.Close()
+	return destConn.Raw(func(dest any) error {
+		return srcConn.Raw(func(src any) error {
+			backup, err := dest.(*sqlite3.SQLiteConn).Backup("main", src.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return fmt.Errorf("failed to start the backup of %s: %w", path, err)
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return fmt.Errorf("failed to copy %s: %w", path, err)
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// recorder runs the queries of sqlc in the transaction and keeps the writes
+type recorder struct {
+	tx         *sql.Tx
+	now        string
+	statements []Statement
+	err        error
+}
+
+// insertColumns matches the head of an insert up to its column list, after the comments of sqlc
+var insertColumns = regexp.MustCompile(`(?is)^((?:\s*--[^\n]*\n)*\s*INSERT\s+INTO\s+"?(\w+)"?\s*\()([^)]*)\)\s*VALUES\s*\(`)
+
+// withCreatedAt adds the created_at of the recording to an insert which leaves it to the default.
+// Other queries, and the insert when it fails, are returned as they are.
+func (r *recorder) withCreatedAt(ctx context.Context, query string, args []any) (string, []any, error) {
+	m := insertColumns.FindStringSubmatchIndex(query)
+	if m == nil {
+		return query, args, nil
+	}
+	table, columns := query[m[4]:m[5]], query[m[6]:m[7]]
+	for _, column := range strings.Split(columns, ",") {
+		if strings.EqualFold(strings.Trim(strings.TrimSpace(column), `"`), "created_at") {
+			return query, args, nil
+		}
+	}
+	var hasCreatedAt bool
+	err := r.tx.QueryRowContext(ctx, "SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = 'created_at'", table).Scan(&hasCreatedAt)
+	if err != nil {
+		return query, args, fmt.Errorf("failed to read the columns of %s: %w", table, err)
+	}
+	if !hasCreatedAt {
+		return query, args, nil
+	}
+	// the closing parenthesis of the values
+	end, depth := -1, 1
+	for i := m[1]; i < len(query) && end < 0; i++ {
+		switch query[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return query, args, fmt.Errorf("unsupported insert into %s: %q", table, query)
+	}
+	// the placeholders before it are the args before the new one
+	before := strings.Count(query[:end], "?")
+	if before > len(args) {
+		return query, args, fmt.Errorf("unsupported insert into %s: %q", table, query)
+	}
+	query = query[:m[7]] + ", created_at" + query[m[7]:end] + ", ?" + query[end:]
+	return query, slices.Insert(slices.Clone(args), before, any(r.now)), nil
+}
+
+func (r *recorder) record(query string, args []any) {
+	if isSelect(query) || r.err != nil {
+		return
+	}
+	statement, err := NewStatement(query, args...)
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.statements = append(r.statements, statement)
+}
+
+func (r *recorder) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, args, err := r.withCreatedAt(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.tx.ExecContext(ctx, query, args...)
+	if err == nil {
+		r.record(query, args)
+	}
+	return result, err
+}
+
+func (r *recorder) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements can't be recorded")
+}
+
+func (r *recorder) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args, err := r.withCreatedAt(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.tx.QueryContext(ctx, query, args...)
+	if err == nil {
+		r.record(query, args)
+	}
+	return rows, err
+}
+
+// QueryRowContext records the query even when it fails, the error only shows when the row is scanned
+func (r *recorder) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query, args, err := r.withCreatedAt(ctx, query, args)
+	if err != nil && r.err == nil {
+		// a row can't carry the error, the recording fails with it instead
+		r.err = err
+	}
+	r.record(query, args)
+	return r.tx.QueryRowContext(ctx, query, args...)
+}
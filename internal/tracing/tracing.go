@@ -0,0 +1,144 @@
+// Package tracing exports OpenTelemetry spans of the requests, the db queries and the audit writes
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tomek7667/secrets/internal/metrics"
+	"github.com/tomek7667/secrets/internal/sqlc"
+)
+
+const (
+	// ServiceName is the default service.name, OTEL_SERVICE_NAME overrides it
+	ServiceName = "secretsserver"
+
+	instrumentationName = "github.com/tomek7667/secrets"
+)
+
+// Propagator reads and writes the W3C traceparent, tracestate and baggage headers
+var Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Tracer starts the spans of the server, they go nowhere until Setup
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup exports the spans with the exporter:
+//   - "otlp" sends them over OTLP/HTTP, "otlp-grpc" over OTLP/gRPC, both configured with the
+//     standard OTEL_EXPORTER_OTLP_* variables, e.g. OTEL_EXPORTER_OTLP_ENDPOINT=http://collector:4318
+//   - "stdout" prints them, "file:<path>" appends them to the file, as json, for local testing
+//
+// The returned shutdown flushes the spans not exported yet.
+func Setup(ctx context.Context, exporter, version string) (func(context.Context) error, error) {
+	spanExporter, err := newExporter(ctx, exporter)
+	if err != nil {
+		return nil, err
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES win over the defaults
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(ServiceName), semconv.ServiceVersion(version)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(Propagator)
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, exporter string) (sdktrace.SpanExporter, error) {
+	switch {
+	case exporter == "otlp":
+		return otlptracehttp.New(ctx)
+	case exporter == "otlp-grpc":
+		return otlptracegrpc.New(ctx)
+	case exporter == "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case strings.HasPrefix(exporter, "file:"):
+		path := strings.TrimPrefix(exporter, "file:")
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the trace file: %w", err)
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	}
+	return nil, fmt.Errorf("unknown trace exporter '%s', expected otlp, otlp-grpc, stdout or file:<path>", exporter)
+}
+
+// End records the error of the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// DB starts a span for every query run through db, named after the sqlc query, e.g. "GetSecret"
+func DB(db sqlc.DBTX, system string) sqlc.DBTX {
+	return tracedDB{db: db, system: system}
+}
+
+type tracedDB struct {
+	db     sqlc.DBTX
+	system string
+}
+
+func (t tracedDB) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := metrics.QueryName(query)
+	return Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", t.system),
+			attribute.String("db.operation.name", name),
+			attribute.String("db.query.text", query),
+		),
+	)
+}
+
+func (t tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+	result, err := t.db.ExecContext(ctx, query, args...)
+	End(span, err)
+	return result, err
+}
+
+func (t tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.db.PrepareContext(ctx, query)
+}
+
+func (t tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.start(ctx, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	End(span, err)
+	return rows, err
+}
+
+func (t tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.start(ctx, query)
+	row := t.db.QueryRowContext(ctx, query, args...)
+	End(span, row.Err())
+	return row
+}
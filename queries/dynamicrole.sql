@@ -0,0 +1,40 @@
+-- name: CreateDynamicRole :one
+INSERT INTO dynamic_role (
+    id,
+    name,
+    engine,
+    config,
+    default_ttl_seconds,
+    max_ttl_seconds
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetDynamicRole :one
+SELECT *
+FROM dynamic_role
+WHERE id = ?;
+
+-- name: GetDynamicRoleByName :one
+SELECT *
+FROM dynamic_role
+WHERE name = ?;
+
+-- name: ListDynamicRoles :many
+SELECT *
+FROM dynamic_role
+ORDER BY created_at DESC;
+
+-- name: UpdateDynamicRole :one
+UPDATE dynamic_role
+SET
+    config = ?,
+    default_ttl_seconds = ?,
+    max_ttl_seconds = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteDynamicRole :exec
+DELETE FROM dynamic_role
+WHERE id = ?;
@@ -0,0 +1,70 @@
+-- name: CreateLease :one
+INSERT INTO lease (
+    id,
+    role_id,
+    secret_key,
+    token_id,
+    username,
+    expires_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetLease :one
+SELECT *
+FROM lease
+WHERE id = ?;
+
+-- name: ListLeases :many
+SELECT *
+FROM lease
+ORDER BY created_at DESC;
+
+-- name: ListActiveLeasesByRoleId :many
+SELECT *
+FROM lease
+WHERE role_id = ? AND revoked_at IS NULL;
+
+-- name: ListActiveLeasesBySecretKey :many
+SELECT *
+FROM lease
+WHERE secret_key = ? AND revoked_at IS NULL;
+
+-- name: ListActiveLeasesByTokenId :many
+SELECT *
+FROM lease
+WHERE token_id = ? AND revoked_at IS NULL;
+
+-- name: ListExpiredLeases :many
+SELECT *
+FROM lease
+WHERE revoked_at IS NULL AND expires_at <= ?
+ORDER BY expires_at;
+
+-- name: RenewLease :one
+UPDATE lease
+SET
+    expires_at = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: MarkLeasesStale :exec
+UPDATE lease
+SET
+    stale_at = ?
+WHERE secret_key = ? AND revoked_at IS NULL AND stale_at IS NULL;
+
+-- name: RevokeLease :one
+UPDATE lease
+SET
+    revoked_at = ?,
+    last_error = NULL
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateLeaseError :exec
+UPDATE lease
+SET
+    last_error = ?
+WHERE id = ?;
@@ -34,3 +34,10 @@ SET
     secret_key_pattern = ?
 WHERE id = ?
 RETURNING *;
+
+-- name: ListOrphanedPermissions :many
+SELECT permission.*
+FROM permission
+LEFT JOIN token ON token.id = permission.token_id
+WHERE token.id IS NULL
+ORDER BY permission.created_at DESC;
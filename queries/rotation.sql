@@ -0,0 +1,53 @@
+-- name: CreateRotationPolicy :one
+INSERT INTO rotation_policy (
+    id,
+    secret_key,
+    interval_seconds,
+    generator,
+    next_rotation_at
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetRotationPolicy :one
+SELECT *
+FROM rotation_policy
+WHERE id = ?;
+
+-- name: ListRotationPolicies :many
+SELECT *
+FROM rotation_policy
+ORDER BY created_at DESC;
+
+-- name: ListDueRotationPolicies :many
+SELECT *
+FROM rotation_policy
+WHERE next_rotation_at <= ?
+ORDER BY next_rotation_at;
+
+-- name: UpdateRotationPolicy :one
+UPDATE rotation_policy
+SET
+    interval_seconds = ?,
+    generator = ?,
+    next_rotation_at = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateRotationPolicyResult :one
+UPDATE rotation_policy
+SET
+    last_rotated_at = ?,
+    next_rotation_at = ?,
+    last_error = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteRotationPolicy :exec
+DELETE FROM rotation_policy
+WHERE id = ?;
+
+-- name: DeleteRotationPolicyBySecretKey :exec
+DELETE FROM rotation_policy
+WHERE secret_key = ?;
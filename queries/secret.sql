@@ -2,9 +2,11 @@
 INSERT INTO secret (
     id,
     key,
-    value
+    value,
+    expires_at,
+    rotate_by
 ) VALUES (
-    ?, ?, ?
+    ?, ?, ?, ?, ?
 )
 RETURNING *;
 
@@ -22,9 +24,37 @@ SELECT *
 FROM secret
 ORDER BY created_at DESC;
 
+-- name: ListSecretsByKeyPattern :many
+SELECT *
+FROM secret
+WHERE key LIKE sqlc.arg(pattern) ESCAPE '\'
+ORDER BY created_at DESC;
+
 -- name: UpdateSecret :one
 UPDATE secret
 SET
     value = ?
 WHERE key = ?
 RETURNING *;
+
+-- name: UpdateSecretDates :one
+UPDATE secret
+SET
+    expires_at = ?,
+    rotate_by = ?
+WHERE key = ?
+RETURNING *;
+
+-- name: ListExpiringSecrets :many
+SELECT *
+FROM secret
+WHERE (expires_at IS NOT NULL AND expires_at <= sqlc.arg(before))
+    OR (rotate_by IS NOT NULL AND rotate_by <= sqlc.arg(before))
+ORDER BY CASE
+    WHEN rotate_by IS NULL OR expires_at < rotate_by THEN COALESCE(expires_at, rotate_by)
+    ELSE rotate_by
+END;
+
+-- name: CountSecrets :one
+SELECT COUNT(*)
+FROM secret;
@@ -0,0 +1,32 @@
+-- name: CreateSecretVersion :one
+INSERT INTO secret_version (
+    id,
+    secret_key,
+    version,
+    value
+) VALUES (
+    ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetLatestSecretVersion :one
+SELECT CAST(COALESCE(MAX(version), 0) AS INTEGER) AS version
+FROM secret_version
+WHERE secret_key = ?;
+
+-- name: ListSecretVersions :many
+SELECT *
+FROM secret_version
+WHERE secret_key = ?
+ORDER BY version DESC;
+
+-- name: DeleteSecretVersions :exec
+DELETE FROM secret_version
+WHERE secret_key = ?;
+
+-- name: GetNewestSecretVersion :one
+SELECT *
+FROM secret_version
+WHERE secret_key = ?
+ORDER BY version DESC
+LIMIT 1;
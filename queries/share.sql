@@ -0,0 +1,37 @@
+-- name: CreateShare :one
+INSERT INTO share (
+    id,
+    created_by,
+    ciphertext,
+    nonce,
+    max_views,
+    expires_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: ListShares :many
+SELECT *
+FROM share
+ORDER BY created_at DESC;
+
+-- name: GetShare :one
+SELECT *
+FROM share
+WHERE id = ?;
+
+-- name: ViewShare :one
+UPDATE share
+SET
+    views = views + 1
+WHERE id = sqlc.arg(id) AND views < max_views AND expires_at > sqlc.arg(now)
+RETURNING *;
+
+-- name: DeleteShare :exec
+DELETE FROM share
+WHERE id = ?;
+
+-- name: DeleteExpiredShares :exec
+DELETE FROM share
+WHERE expires_at <= ? OR views >= max_views;
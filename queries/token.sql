@@ -33,3 +33,7 @@ SET
     expires_at = ?
 WHERE id = ?
 RETURNING *;
+
+-- name: CountTokens :one
+SELECT COUNT(*)
+FROM token;
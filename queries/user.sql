@@ -1,5 +1,5 @@
 -- name: CreateUser :one
-INSERT INTO user (
+INSERT INTO "user" (
     id,
     username,
     password
@@ -10,25 +10,25 @@ RETURNING *;
 
 -- name: GetUser :one
 SELECT *
-FROM user
+FROM "user"
 WHERE id = ?;
 
 -- name: GetUserByUsername :one
 SELECT *
-FROM user
+FROM "user"
 WHERE username = ?;
 
 -- name: DeleteUser :exec
-DELETE FROM user
+DELETE FROM "user"
 WHERE id = ?;
 
 -- name: ListUsers :many
 SELECT *
-FROM user
+FROM "user"
 ORDER BY created_at DESC;
 
 -- name: UpdateUser :one
-UPDATE user
+UPDATE "user"
 SET
     password = ?
 WHERE id = ?
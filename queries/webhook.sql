@@ -0,0 +1,78 @@
+-- name: CreateWebhook :one
+INSERT INTO webhook (
+    id,
+    url,
+    key_pattern,
+    events,
+    signing_secret
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetWebhook :one
+SELECT *
+FROM webhook
+WHERE id = ?;
+
+-- name: ListWebhooks :many
+SELECT *
+FROM webhook
+ORDER BY created_at DESC;
+
+-- name: UpdateWebhook :one
+UPDATE webhook
+SET
+    url = ?,
+    key_pattern = ?,
+    events = ?,
+    signing_secret = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhook
+WHERE id = ?;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_delivery (
+    id,
+    webhook_id,
+    event,
+    secret_key,
+    payload,
+    next_attempt_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: ListDueWebhookDeliveries :many
+SELECT *
+FROM webhook_delivery
+WHERE status = 'pending' AND next_attempt_at <= ?
+ORDER BY next_attempt_at
+LIMIT ?;
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_delivery
+WHERE webhook_id = ?
+ORDER BY created_at DESC
+LIMIT ?;
+
+-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_delivery
+SET
+    status = ?,
+    attempts = ?,
+    next_attempt_at = ?,
+    last_status_code = ?,
+    last_error = ?,
+    delivered_at = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteWebhookDeliveries :exec
+DELETE FROM webhook_delivery
+WHERE webhook_id = ?;
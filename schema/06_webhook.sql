@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    url TEXT NOT NULL,
+    key_pattern TEXT NOT NULL,
+    events TEXT NOT NULL,
+    signing_secret TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    webhook_id TEXT NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    secret_key TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_status_code INTEGER,
+    last_error TEXT,
+    delivered_at DATETIME
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS webhook_delivery_status_next_attempt_at ON webhook_delivery (status, next_attempt_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_delivery;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE webhook;
+-- +goose StatementEnd
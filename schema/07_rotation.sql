@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS rotation_policy (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    secret_key TEXT NOT NULL UNIQUE,
+    interval_seconds INTEGER NOT NULL,
+    generator TEXT NOT NULL,
+    last_rotated_at DATETIME,
+    next_rotation_at DATETIME NOT NULL,
+    last_error TEXT
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS secret_version (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    secret_key TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (secret_key, version)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE secret_version;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE rotation_policy;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE secret ADD COLUMN expires_at DATETIME;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE secret ADD COLUMN rotate_by DATETIME;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE secret DROP COLUMN rotate_by;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE secret DROP COLUMN expires_at;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE lease_new (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    role_id TEXT REFERENCES dynamic_role(id) ON DELETE CASCADE,
+    secret_key TEXT,
+    token_id TEXT,
+    username TEXT,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    stale_at DATETIME,
+    last_error TEXT
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO lease_new (id, created_at, role_id, token_id, username, expires_at, revoked_at, last_error)
+SELECT id, created_at, role_id, token_id, username, expires_at, revoked_at, last_error
+FROM lease;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE lease;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE lease_new RENAME TO lease;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS lease_revoked_at_expires_at ON lease (revoked_at, expires_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS lease_secret_key ON lease (secret_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM lease
+WHERE role_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX lease_secret_key;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE lease DROP COLUMN stale_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE lease DROP COLUMN secret_key;
+-- +goose StatementEnd
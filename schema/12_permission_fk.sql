@@ -0,0 +1,64 @@
+-- +goose Up
+-- foreign keys were never enforced before, so the rows they should have removed are cleaned up once
+-- +goose StatementBegin
+DELETE FROM permission WHERE token_id NOT IN (SELECT id FROM token);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DELETE FROM webhook_delivery WHERE webhook_id NOT IN (SELECT id FROM webhook);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DELETE FROM lease WHERE role_id IS NOT NULL AND role_id NOT IN (SELECT id FROM dynamic_role);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE permission_new (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    token_id TEXT NOT NULL REFERENCES token(id) ON DELETE CASCADE,
+    secret_key_pattern TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO permission_new (id, created_at, token_id, secret_key_pattern)
+SELECT id, created_at, token_id, secret_key_pattern
+FROM permission;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE permission;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE permission_new RENAME TO permission;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS permission_token_id ON permission (token_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE permission_old (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    token_id TEXT NOT NULL,
+    secret_key_pattern TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO permission_old (id, created_at, token_id, secret_key_pattern)
+SELECT id, created_at, token_id, secret_key_pattern
+FROM permission;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE permission;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE permission_old RENAME TO permission;
+-- +goose StatementEnd
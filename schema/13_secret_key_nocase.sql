@@ -0,0 +1,10 @@
+-- +goose Up
+-- sqlite's LIKE ignores the case of ASCII letters, so the prefix of a LIKE pattern is looked up in a NOCASE index
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS secret_key_nocase ON secret (key COLLATE NOCASE);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX secret_key_nocase;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- the raft index the db is at and the api urls of the nodes of a cluster, replicated like the rest of the db.
+-- Only sqlite runs as a cluster.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS cluster_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    applied_index INTEGER NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT OR IGNORE INTO cluster_state (id, applied_index) VALUES (1, 0);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS cluster_node (
+    id TEXT PRIMARY KEY,
+    address TEXT NOT NULL,
+    url TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE cluster_node;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE cluster_state;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+// Package schema embeds the goose migrations, which the server applies on startup
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var Migrations embed.FS
+
+// PostgresMigrations hold the same schema for postgres, a change to the sqlite schema needs a postgres migration too
+//
+//go:embed postgres/*.sql
+var PostgresMigrations embed.FS
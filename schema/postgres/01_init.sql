@@ -0,0 +1,207 @@
+-- +goose Up
+-- the sqlite schema as of 12_permission_fk.sql
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS secret (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    key TEXT NOT NULL UNIQUE,
+    value TEXT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    rotate_by TIMESTAMPTZ
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "user" (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    username TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS token (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ,
+    token TEXT NOT NULL UNIQUE
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS permission (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    token_id TEXT NOT NULL REFERENCES token(id) ON DELETE CASCADE,
+    secret_key_pattern TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS permission_token_id ON permission (token_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS log (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    event TEXT NOT NULL,
+    msg TEXT NOT NULL,
+    requested_url TEXT,
+    remote_addr TEXT
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    url TEXT NOT NULL,
+    key_pattern TEXT NOT NULL,
+    events TEXT NOT NULL,
+    signing_secret TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    webhook_id TEXT NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    secret_key TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_status_code BIGINT,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS webhook_delivery_status_next_attempt_at ON webhook_delivery (status, next_attempt_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS rotation_policy (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    secret_key TEXT NOT NULL UNIQUE,
+    interval_seconds BIGINT NOT NULL,
+    generator TEXT NOT NULL,
+    last_rotated_at TIMESTAMPTZ,
+    next_rotation_at TIMESTAMPTZ NOT NULL,
+    last_error TEXT
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS secret_version (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    secret_key TEXT NOT NULL,
+    version BIGINT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (secret_key, version)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS dynamic_role (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    name TEXT NOT NULL UNIQUE,
+    engine TEXT NOT NULL,
+    config TEXT NOT NULL,
+    default_ttl_seconds BIGINT NOT NULL,
+    max_ttl_seconds BIGINT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS lease (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    role_id TEXT REFERENCES dynamic_role(id) ON DELETE CASCADE,
+    secret_key TEXT,
+    token_id TEXT,
+    username TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    stale_at TIMESTAMPTZ,
+    last_error TEXT
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS lease_revoked_at_expires_at ON lease (revoked_at, expires_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS lease_secret_key ON lease (secret_key);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS share (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    created_by TEXT NOT NULL,
+    ciphertext TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    max_views BIGINT NOT NULL,
+    views BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE share;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE lease;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE dynamic_role;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE secret_version;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE rotation_policy;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE webhook_delivery;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE webhook;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE log;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE permission;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE token;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE "user";
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE secret;
+-- +goose StatementEnd
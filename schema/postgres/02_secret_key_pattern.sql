@@ -0,0 +1,10 @@
+-- +goose Up
+-- the prefix of a LIKE pattern is looked up in a text_pattern_ops index whatever the collation of the db
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS secret_key_pattern ON secret (key text_pattern_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX secret_key_pattern;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+-- +goose Up
+-- deleting a secret left its versions behind, which a new secret of the same key showed as its history
+-- +goose StatementBegin
+DELETE FROM secret_version WHERE secret_key NOT IN (SELECT key FROM secret);
+-- +goose StatementEnd
+
+-- +goose Down
+-- the deleted versions can't be brought back
@@ -5,6 +5,7 @@ type Client struct {
 	Token   string
 }
 
+// New checks that the server is reachable, and logs a warning when its version isn't the sdk's
 func New(baseUrl, token string) (*Client, error) {
 	c := &Client{
 		BaseUrl: baseUrl,
@@ -13,5 +14,6 @@ func New(baseUrl, token string) (*Client, error) {
 	if err := c.Ping(); err != nil {
 		return nil, err
 	}
+	c.checkVersion()
 	return c, nil
 }
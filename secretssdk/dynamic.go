@@ -0,0 +1,57 @@
+package secretssdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type DynamicCredentials struct {
+	LeaseID   string    `json:"id"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type dynamicCredentialsResponse struct {
+	Success bool               `json:"success"`
+	Data    DynamicCredentials `json:"data"`
+}
+
+// GetDynamicCredentialsWithCtx issues new credentials of a dynamic role. A zero ttl uses the role default.
+func (c *Client) GetDynamicCredentialsWithCtx(role string, ttl time.Duration, ctx context.Context) (*DynamicCredentials, error) {
+	endpoint := fmt.Sprintf("%s/api/dynamic/creds?role=%s", c.BaseUrl, url.QueryEscape(role))
+	if ttl > 0 {
+		endpoint += "&ttl=" + url.QueryEscape(ttl.String())
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new request for endpoint '%s': %w", endpoint, err)
+	}
+	req = req.WithContext(ctx)
+	resp, err := c.GetHttpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed for role '%s': %w", role, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("unauthorized: token lacks permission for role '%s'", role)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d for role '%s'", resp.StatusCode, role)
+	}
+
+	var result dynamicCredentialsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode credentials of role '%s': %w", role, err)
+	}
+	return &result.Data, nil
+}
+
+func (c *Client) GetDynamicCredentials(role string, ttl time.Duration) (*DynamicCredentials, error) {
+	return c.GetDynamicCredentialsWithCtx(role, ttl, context.Background())
+}
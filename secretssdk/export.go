@@ -0,0 +1,66 @@
+package secretssdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type ExportOptions struct {
+	// Format is one of env, json, yaml or shell
+	Format string
+	// Prefix limits the export to the keys starting with it
+	Prefix string
+	// StripPrefix removes the prefix from the exported keys
+	StripPrefix bool
+	// EnvNames turns the keys into env names, e.g. "aws/access-key" becomes "AWS_ACCESS_KEY"
+	EnvNames bool
+}
+
+// ExportSecretsWithCtx returns the secrets the token can access rendered as a file, e.g. to write a .env
+func (c *Client) ExportSecretsWithCtx(opts ExportOptions, ctx context.Context) ([]byte, error) {
+	query := url.Values{
+		"format":       {opts.Format},
+		"prefix":       {opts.Prefix},
+		"strip_prefix": {strconv.FormatBool(opts.StripPrefix)},
+		"env_names":    {strconv.FormatBool(opts.EnvNames)},
+	}
+	endpoint := fmt.Sprintf("%s/api/secrets/export?%s", c.BaseUrl, query.Encode())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new request for endpoint '%s': %w", endpoint, err)
+	}
+	req = req.WithContext(ctx)
+	resp, err := c.GetHttpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed for exporting secrets: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("unauthorized: token can't export secrets")
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		var result struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return nil, fmt.Errorf("invalid export: %s", result.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d for exporting secrets", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the export: %w", err)
+	}
+	return data, nil
+}
+
+func (c *Client) ExportSecrets(opts ExportOptions) ([]byte, error) {
+	return c.ExportSecretsWithCtx(opts, context.Background())
+}
@@ -5,14 +5,21 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type Secret struct {
-	ID    string `json:"id"`
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	ID        string     `json:"id"`
+	Key       string     `json:"key"`
+	Value     string     `json:"value"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RotateBy  *time.Time `json:"rotate_by"`
+	// LeaseID is set when the secret was read with GetSecretLeased
+	LeaseID        *string    `json:"lease_id"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
 }
 
 type secretResponse struct {
@@ -21,7 +28,24 @@ type secretResponse struct {
 }
 
 func (c *Client) GetSecretWithCtx(key string, ctx context.Context) (*Secret, error) {
+	return c.getSecret(key, 0, ctx)
+}
+
+// GetSecretLeasedWithCtx reads the secret and takes a lease on it for ttl, so that the server knows
+// the value is in use. Keep the lease with RenewLease while the value is held.
+func (c *Client) GetSecretLeasedWithCtx(key string, ttl time.Duration, ctx context.Context) (*Secret, error) {
+	return c.getSecret(key, ttl, ctx)
+}
+
+func (c *Client) GetSecretLeased(key string, ttl time.Duration) (*Secret, error) {
+	return c.GetSecretLeasedWithCtx(key, ttl, context.Background())
+}
+
+func (c *Client) getSecret(key string, leaseTtl time.Duration, ctx context.Context) (*Secret, error) {
 	endpoint := fmt.Sprintf("%s/api/secrets/get?key=%s", c.BaseUrl, url.QueryEscape(key))
+	if leaseTtl > 0 {
+		endpoint += "&lease=" + url.QueryEscape(leaseTtl.String())
+	}
 	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a new request for endpoint '%s': %w", endpoint, err)
@@ -44,6 +68,9 @@ func (c *Client) GetSecretWithCtx(key string, ctx context.Context) (*Secret, err
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response for secret '%s': %w", key, err)
 	}
+	for _, warning := range resp.Header.Values("Warning") {
+		slog.Warn("secrets server warning", "key", key, "warning", warning)
+	}
 
 	decoded, err := base64.StdEncoding.DecodeString(result.Data.Value)
 	if err != nil {
@@ -1,6 +1,13 @@
 package secretssdk
 
-import "net/http"
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// propagator sends the trace of the request's context to the server, in the W3C traceparent header
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
 
 type authTransport struct {
 	token string
@@ -9,6 +16,7 @@ type authTransport struct {
 
 func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.Header.Set("Authorization", "Api "+t.token)
+	propagator.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
 	return t.base.RoundTrip(req)
 }
 
@@ -0,0 +1,60 @@
+package secretssdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrLeaseNotRenewable is returned when the lease expired, was revoked or the secret changed since it was read.
+// Read the secret again to get a new lease.
+var ErrLeaseNotRenewable = errors.New("lease can't be renewed")
+
+type Lease struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type leaseResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    Lease  `json:"data"`
+}
+
+// RenewLeaseWithCtx extends the lease to expire ttl from now (at most a day)
+func (c *Client) RenewLeaseWithCtx(leaseID string, ttl time.Duration, ctx context.Context) (*Lease, error) {
+	endpoint := fmt.Sprintf("%s/api/leases/%s/renew?ttl=%s", c.BaseUrl, url.PathEscape(leaseID), url.QueryEscape(ttl.String()))
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new request for endpoint '%s': %w", endpoint, err)
+	}
+	req = req.WithContext(ctx)
+	resp, err := c.GetHttpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed for lease '%s': %w", leaseID, err)
+	}
+	defer resp.Body.Close()
+
+	var result leaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response for lease '%s': %w", leaseID, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return &result.Data, nil
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("unauthorized: invalid token")
+	case http.StatusBadRequest, http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrLeaseNotRenewable, result.Message)
+	default:
+		return nil, fmt.Errorf("unexpected status %d for lease '%s'", resp.StatusCode, leaseID)
+	}
+}
+
+func (c *Client) RenewLease(leaseID string, ttl time.Duration) (*Lease, error) {
+	return c.RenewLeaseWithCtx(leaseID, ttl, context.Background())
+}
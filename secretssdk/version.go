@@ -0,0 +1,59 @@
+package secretssdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/tomek7667/secrets/internal/buildinfo"
+)
+
+type versionResponse struct {
+	Success bool           `json:"success"`
+	Data    buildinfo.Info `json:"data"`
+}
+
+// Version is the version of the sdk, the one of the github.com/tomek7667/secrets module the application uses
+func Version() string {
+	return buildinfo.Read().Version
+}
+
+// ServerVersion is the version the server was built from
+func (c *Client) ServerVersion() (string, error) {
+	endpoint := fmt.Sprintf("%s/version", c.BaseUrl)
+
+	resp, err := http.Get(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("server unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d for the server version", resp.StatusCode)
+	}
+
+	var result versionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("invalid server response: %w", err)
+	}
+	return result.Data.Version, nil
+}
+
+// checkVersion warns when the server isn't of the sdk's version, the api may have changed in between.
+// The builds without a version (go run, go test) are compatible with every version.
+func (c *Client) checkVersion() {
+	serverVersion, err := c.ServerVersion()
+	if err != nil {
+		slog.Warn("failed to check the version of the secrets server", "err", err)
+		return
+	}
+	sdkVersion := Version()
+	if serverVersion == buildinfo.Devel || sdkVersion == buildinfo.Devel || serverVersion == sdkVersion {
+		return
+	}
+	slog.Warn(
+		"the secrets server and sdk versions differ, some calls may not work",
+		"server", serverVersion,
+		"sdk", sdkVersion,
+	)
+}
@@ -0,0 +1,69 @@
+package secretssdk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type SecretChange struct {
+	Event string    `json:"event"`
+	Key   string    `json:"key"`
+	Ts    time.Time `json:"ts"`
+}
+
+// WatchWithCtx blocks and calls onChange for every change of a secret matching pattern
+// that the token can access. It returns when ctx is done or the stream ends, callers are
+// expected to re-read their secrets and call it again.
+func (c *Client) WatchWithCtx(ctx context.Context, pattern string, onChange func(SecretChange)) error {
+	endpoint := fmt.Sprintf("%s/api/secrets/watch?pattern=%s", c.BaseUrl, url.QueryEscape(pattern))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create a new request for endpoint '%s': %w", endpoint, err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.GetHttpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed for watching '%s': %w", pattern, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("unauthorized: token can't watch secrets")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d for watching '%s'", resp.StatusCode, pattern)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				var change SecretChange
+				if err := json.Unmarshal([]byte(data.String()), &change); err != nil {
+					return fmt.Errorf("failed to decode change event: %w", err)
+				}
+				onChange(change)
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+func (c *Client) Watch(pattern string, onChange func(SecretChange)) error {
+	return c.WatchWithCtx(context.Background(), pattern, onChange)
+}
@@ -3,6 +3,7 @@ import { useRouter } from "./hooks/useRouter";
 import { useToast } from "./hooks/useToast";
 import { Login } from "./pages/Login";
 import { Dashboard } from "./pages/Dashboard";
+import { SharePage } from "./pages/SharePage";
 import { ToastContainer } from "./components/Toast";
 
 declare global {
@@ -18,6 +19,11 @@ export default function App() {
 
 	const turnstileSiteKey = window.TURNSTILE_SITE_KEY;
 
+	const shareMatch = window.location.pathname.match(/^\/s\/([^/]+)$/);
+	if (shareMatch) {
+		return <SharePage id={shareMatch[1]} />;
+	}
+
 	return (
 		<>
 			{isAuthenticated ? (
@@ -1,4 +1,18 @@
-import type { ApiResponse, Secret, User, Token, Permission } from "./types";
+import type {
+	ApiResponse,
+	Secret,
+	User,
+	Token,
+	Permission,
+	GeneratorOptions,
+	Share,
+	CreatedShare,
+	RevealedShare,
+	ImportResult,
+	ImportFormat,
+	ExportFormat,
+	ConflictPolicy,
+} from "./types";
 
 const getToken = (): string | null => localStorage.getItem("jwt");
 
@@ -13,7 +27,8 @@ async function request<T>(
 ): Promise<T> {
 	const token = getToken();
 	const headers: Record<string, string> = {
-		"Content-Type": "application/json",
+		// string bodies are sent as they are, e.g. files to import
+		"Content-Type": typeof body === "string" ? "text/plain" : "application/json",
 	};
 	if (token) {
 		headers["Authorization"] = `Bearer ${token}`;
@@ -22,7 +37,12 @@ async function request<T>(
 	const response = await fetch(url, {
 		method,
 		headers,
-		body: body ? JSON.stringify(body) : undefined,
+		body:
+			typeof body === "string"
+				? body
+				: body
+					? JSON.stringify(body)
+					: undefined,
 	});
 
 	const data = await response.json();
@@ -64,14 +84,67 @@ export const api = {
 
 	secrets: {
 		list: () => request<Secret[]>("GET", "/api/secrets"),
-		create: (key: string, value: string) =>
-			request<Secret>("POST", "/api/secrets", { key, value }),
+		create: (key: string, value: string, generate?: GeneratorOptions) =>
+			request<Secret>("POST", "/api/secrets", { key, value, generate }),
 		update: (key: string, value: string) =>
 			request<Secret>("PUT", `/api/secrets?key=${encodeURIComponent(key)}`, {
 				value,
 			}),
 		delete: (key: string) =>
 			request<void>("DELETE", `/api/secrets?key=${encodeURIComponent(key)}`),
+		updateDates: (key: string, expiresAt?: string, rotateBy?: string) =>
+			request<Secret>(
+				"PUT",
+				`/api/secrets/dates?key=${encodeURIComponent(key)}`,
+				{
+					expires_at: expiresAt || null,
+					rotate_by: rotateBy || null,
+				}
+			),
+		import: (
+			content: string,
+			format: ImportFormat,
+			conflict: ConflictPolicy,
+			dryRun: boolean,
+			prefix = ""
+		) =>
+			request<ImportResult>(
+				"POST",
+				`/api/secrets/import?${new URLSearchParams({
+					format,
+					conflict,
+					prefix,
+					dry_run: String(dryRun),
+				})}`,
+				content
+			),
+		// the export is a file, not the usual json response
+		export: async (
+			format: ExportFormat,
+			prefix: string,
+			stripPrefix: boolean,
+			envNames: boolean
+		): Promise<string> => {
+			const response = await fetch(
+				`/api/secrets/export?${new URLSearchParams({
+					format,
+					prefix,
+					strip_prefix: String(stripPrefix),
+					env_names: String(envNames),
+				})}`,
+				{ headers: { Authorization: `Bearer ${getToken()}` } }
+			);
+			if (!response.ok) {
+				const data = await response.json();
+				throw new Error(data.message || "Request failed");
+			}
+			return response.text();
+		},
+		expiring: (within = "720h") =>
+			request<Secret[]>(
+				"GET",
+				`/api/secrets/expiring?within=${encodeURIComponent(within)}`
+			),
 	},
 
 	users: {
@@ -82,6 +155,23 @@ export const api = {
 			request<void>("DELETE", `/api/users/${encodeURIComponent(id)}`),
 	},
 
+	shares: {
+		list: () => request<Share[]>("GET", "/api/shares"),
+		create: (value: string, maxViews: number, expiresIn: string) =>
+			request<CreatedShare>("POST", "/api/shares", {
+				value,
+				max_views: maxViews,
+				expires_in: expiresIn,
+			}),
+		delete: (id: string) =>
+			request<void>("DELETE", `/api/shares/${encodeURIComponent(id)}`),
+		reveal: (id: string) =>
+			request<RevealedShare>(
+				"POST",
+				`/api/shares/${encodeURIComponent(id)}/reveal`
+			),
+	},
+
 	tokens: {
 		list: () => request<Token[]>("GET", "/api/tokens"),
 		create: (token: string, expiresAt?: string) =>
@@ -1,4 +1,11 @@
-import { KeyRound, Users, Ticket, Shield } from "lucide-react";
+import {
+	KeyRound,
+	CalendarClock,
+	Link,
+	Users,
+	Ticket,
+	Shield,
+} from "lucide-react";
 import type { Route } from "../hooks/useRouter";
 
 interface Tab {
@@ -9,6 +16,8 @@ interface Tab {
 
 const tabs: Tab[] = [
 	{ id: "secrets", label: "Secrets", icon: KeyRound },
+	{ id: "expiring", label: "Expiring", icon: CalendarClock },
+	{ id: "shares", label: "Shares", icon: Link },
 	{ id: "users", label: "Users", icon: Users },
 	{ id: "tokens", label: "Tokens", icon: Ticket },
 	{ id: "permissions", label: "Permissions", icon: Shield },
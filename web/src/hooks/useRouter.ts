@@ -1,8 +1,21 @@
 import { useState, useEffect, useCallback } from "react";
 
-export type Route = "secrets" | "users" | "tokens" | "permissions";
-
-const validRoutes: Route[] = ["secrets", "users", "tokens", "permissions"];
+export type Route =
+	| "secrets"
+	| "expiring"
+	| "shares"
+	| "users"
+	| "tokens"
+	| "permissions";
+
+const validRoutes: Route[] = [
+	"secrets",
+	"expiring",
+	"shares",
+	"users",
+	"tokens",
+	"permissions",
+];
 
 function getRouteFromHash(): Route {
 	const hash = window.location.hash.slice(1) as Route;
@@ -2,6 +2,8 @@ import { KeyRound, LogOut, ArrowDown, ArrowUp } from "lucide-react";
 import { Tabs } from "../components/Tabs";
 import { Button } from "../components/Button";
 import { SecretsPanel } from "./panels/SecretsPanel";
+import { ExpiringPanel } from "./panels/ExpiringPanel";
+import { SharesPanel } from "./panels/SharesPanel";
 import { UsersPanel } from "./panels/UsersPanel";
 import { TokensPanel } from "./panels/TokensPanel";
 import { PermissionsPanel } from "./panels/PermissionsPanel";
@@ -62,6 +64,8 @@ export function Dashboard({
 
 				<main className="animate-fade-in">
 					{route === "secrets" && <SecretsPanel showToast={showToast} />}
+					{route === "expiring" && <ExpiringPanel showToast={showToast} />}
+					{route === "shares" && <SharesPanel showToast={showToast} />}
 					{route === "users" && <UsersPanel showToast={showToast} />}
 					{route === "tokens" && <TokensPanel showToast={showToast} />}
 					{route === "permissions" && (
@@ -0,0 +1,133 @@
+import { useState } from "react";
+import { Eye, ClipboardCopy, Flame } from "lucide-react";
+import { api } from "../api";
+import { Button } from "../components/Button";
+
+interface SharePageProps {
+	id: string;
+}
+
+function fromBase64(value: string): Uint8Array {
+	const normalized = value.replace(/-/g, "+").replace(/_/g, "/");
+	const padded = normalized + "=".repeat((4 - (normalized.length % 4)) % 4);
+	return Uint8Array.from(atob(padded), (c) => c.charCodeAt(0));
+}
+
+// decrypts the share in the browser, the key from the url fragment never reaches the server
+async function decryptShare(
+	id: string,
+	key: string,
+	ciphertext: string,
+	nonce: string
+): Promise<string> {
+	const cryptoKey = await crypto.subtle.importKey(
+		"raw",
+		fromBase64(key),
+		"AES-GCM",
+		false,
+		["decrypt"]
+	);
+	const plaintext = await crypto.subtle.decrypt(
+		{
+			name: "AES-GCM",
+			iv: fromBase64(nonce),
+			additionalData: new TextEncoder().encode(id),
+		},
+		cryptoKey,
+		fromBase64(ciphertext)
+	);
+	return new TextDecoder().decode(plaintext);
+}
+
+export function SharePage({ id }: SharePageProps) {
+	const [key] = useState(() => window.location.hash.slice(1));
+	const [value, setValue] = useState<string | null>(null);
+	const [viewsLeft, setViewsLeft] = useState(0);
+	const [error, setError] = useState("");
+	const [loading, setLoading] = useState(false);
+	const [copied, setCopied] = useState(false);
+
+	const reveal = async () => {
+		setLoading(true);
+		setError("");
+		let share;
+		try {
+			share = await api.shares.reveal(id);
+		} catch {
+			setError("This link has expired or was already used");
+			setLoading(false);
+			return;
+		}
+		try {
+			setValue(await decryptShare(id, key, share.ciphertext, share.nonce));
+			setViewsLeft(share.max_views - share.views);
+			// drop the key from the address bar and history
+			window.history.replaceState(null, "", window.location.pathname);
+		} catch {
+			setError("Failed to decrypt the secret, the link may be incomplete");
+		} finally {
+			setLoading(false);
+		}
+	};
+
+	const copy = () => {
+		if (value === null) return;
+		navigator.clipboard.writeText(value).then(() => setCopied(true));
+	};
+
+	return (
+		<div className="min-h-screen flex items-center justify-center p-4">
+			<div className="w-full max-w-lg animate-slide-up">
+				<div className="text-center mb-8">
+					<div className="inline-flex items-center justify-center w-16 h-16 rounded-2xl bg-sky-500/10 border border-sky-500/20 mb-4">
+						<Flame size={32} className="text-sky-400" />
+					</div>
+					<h1 className="text-2xl font-bold text-slate-100">Shared Secret</h1>
+					<p className="text-sm text-slate-400 mt-1">
+						{value === null
+							? "Someone shared a secret with you. It can only be viewed a limited number of times."
+							: viewsLeft > 0
+								? `This link can be opened ${viewsLeft} more time${viewsLeft === 1 ? "" : "s"}.`
+								: "This link was burnt, save the secret now."}
+					</p>
+				</div>
+
+				<div className="bg-slate-800/50 backdrop-blur-sm rounded-2xl border border-slate-700 p-6 flex flex-col gap-4">
+					{!key && (
+						<div className="px-3 py-2 text-sm text-red-300 bg-red-500/10 border border-red-500/20 rounded-lg">
+							The link is missing its key, make sure it was copied whole.
+						</div>
+					)}
+
+					{error && (
+						<div className="px-3 py-2 text-sm text-red-300 bg-red-500/10 border border-red-500/20 rounded-lg">
+							{error}
+						</div>
+					)}
+
+					{value === null ? (
+						<Button
+							onClick={reveal}
+							loading={loading}
+							disabled={!key}
+							className="w-full"
+						>
+							<Eye size={16} />
+							Reveal
+						</Button>
+					) : (
+						<>
+							<pre className="font-mono text-sm text-slate-100 bg-slate-900 border border-slate-700 rounded-lg p-3 whitespace-pre-wrap break-all">
+								{value}
+							</pre>
+							<Button variant="secondary" onClick={copy} className="w-full">
+								<ClipboardCopy size={16} />
+								{copied ? "Copied" : "Copy"}
+							</Button>
+						</>
+					)}
+				</div>
+			</div>
+		</div>
+	);
+}
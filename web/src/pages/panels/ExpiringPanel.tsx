@@ -0,0 +1,195 @@
+import { useState, useEffect, FormEvent } from "react";
+import { CalendarClock } from "lucide-react";
+import { api } from "../../api";
+import type { Secret } from "../../types";
+import { Table } from "../../components/Table";
+import { Button } from "../../components/Button";
+import { Input } from "../../components/Input";
+import { Modal } from "../../components/Modal";
+
+interface ExpiringPanelProps {
+	showToast: (message: string, type: "success" | "error" | "info") => void;
+}
+
+const windows = [
+	{ label: "7 days", value: "168h" },
+	{ label: "30 days", value: "720h" },
+	{ label: "90 days", value: "2160h" },
+];
+
+function toLocalInput(date: string | null): string {
+	if (!date) return "";
+	const d = new Date(date);
+	d.setMinutes(d.getMinutes() - d.getTimezoneOffset());
+	return d.toISOString().slice(0, 16);
+}
+
+function DateCell({ date }: { date: string | null }) {
+	if (!date) {
+		return <span className="text-slate-500">—</span>;
+	}
+	const overdue = new Date(date).getTime() < Date.now();
+	return (
+		<span className={overdue ? "text-red-400" : "text-amber-400"}>
+			{new Date(date).toLocaleString()}
+		</span>
+	);
+}
+
+export function ExpiringPanel({ showToast }: ExpiringPanelProps) {
+	const [secrets, setSecrets] = useState<Secret[]>([]);
+	const [loading, setLoading] = useState(true);
+	const [within, setWithin] = useState("720h");
+
+	const [editOpen, setEditOpen] = useState(false);
+	const [editKey, setEditKey] = useState("");
+	const [editExpires, setEditExpires] = useState("");
+	const [editRotateBy, setEditRotateBy] = useState("");
+	const [editLoading, setEditLoading] = useState(false);
+
+	const load = async () => {
+		try {
+			const data = await api.secrets.expiring(within);
+			setSecrets(data);
+		} catch (err) {
+			showToast(
+				err instanceof Error ? err.message : "Failed to load expiring secrets",
+				"error"
+			);
+		} finally {
+			setLoading(false);
+		}
+	};
+
+	useEffect(() => {
+		load();
+	}, [within]);
+
+	const openEdit = (secret: Secret) => {
+		setEditKey(secret.key);
+		setEditExpires(toLocalInput(secret.expires_at));
+		setEditRotateBy(toLocalInput(secret.rotate_by));
+		setEditOpen(true);
+	};
+
+	const handleEdit = async (e: FormEvent) => {
+		e.preventDefault();
+		setEditLoading(true);
+		try {
+			await api.secrets.updateDates(
+				editKey,
+				editExpires ? new Date(editExpires).toISOString() : undefined,
+				editRotateBy ? new Date(editRotateBy).toISOString() : undefined
+			);
+			showToast("Dates updated", "success");
+			setEditOpen(false);
+			load();
+		} catch (err) {
+			showToast(
+				err instanceof Error ? err.message : "Failed to update dates",
+				"error"
+			);
+		} finally {
+			setEditLoading(false);
+		}
+	};
+
+	const columns = [
+		{
+			key: "key",
+			header: "Key",
+			render: (s: Secret) => (
+				<span className="font-mono text-slate-200">{s.key}</span>
+			),
+		},
+		{
+			key: "expires",
+			header: "Expires",
+			render: (s: Secret) => <DateCell date={s.expires_at} />,
+		},
+		{
+			key: "rotate_by",
+			header: "Rotate By",
+			render: (s: Secret) => <DateCell date={s.rotate_by} />,
+		},
+		{
+			key: "actions",
+			header: "",
+			className: "text-right w-1",
+			render: (s: Secret) => (
+				<Button
+					variant="ghost"
+					size="sm"
+					onClick={() => openEdit(s)}
+					title="Edit dates"
+				>
+					<CalendarClock size={14} />
+				</Button>
+			),
+		},
+	];
+
+	return (
+		<div>
+			<div className="flex items-center justify-end gap-1 mb-4">
+				{windows.map((w) => (
+					<Button
+						key={w.value}
+						variant={within === w.value ? "primary" : "secondary"}
+						size="sm"
+						onClick={() => setWithin(w.value)}
+					>
+						{w.label}
+					</Button>
+				))}
+			</div>
+
+			{loading ? (
+				<div className="text-slate-500 py-12 text-center">Loading...</div>
+			) : (
+				<Table
+					columns={columns}
+					data={secrets}
+					keyField="id"
+					emptyMessage="No secrets expire soon"
+				/>
+			)}
+
+			<Modal
+				open={editOpen}
+				onClose={() => setEditOpen(false)}
+				title={`Dates of ${editKey}`}
+			>
+				<form onSubmit={handleEdit} className="flex flex-col gap-4">
+					<Input
+						id="edit-expires"
+						label="Expires At (optional)"
+						type="datetime-local"
+						value={editExpires}
+						onChange={(e) => setEditExpires(e.target.value)}
+					/>
+					<Input
+						id="edit-rotate-by"
+						label="Rotate By (optional)"
+						type="datetime-local"
+						value={editRotateBy}
+						onChange={(e) => setEditRotateBy(e.target.value)}
+					/>
+					<div className="flex gap-3 mt-2">
+						<Button
+							variant="secondary"
+							type="button"
+							onClick={() => setEditOpen(false)}
+							className="flex-1"
+						>
+							Cancel
+						</Button>
+						<Button type="submit" loading={editLoading} className="flex-1">
+							Save
+						</Button>
+					</div>
+				</form>
+			</Modal>
+		</div>
+	);
+}
@@ -6,15 +6,33 @@ import {
 	Search,
 	KeyRound,
 	ClipboardCopy,
+	Upload,
+	Download,
 } from "lucide-react";
 import { api } from "../../api";
-import type { Secret } from "../../types";
+import type {
+	Secret,
+	GeneratorType,
+	ImportFormat,
+	ExportFormat,
+	ConflictPolicy,
+	ImportResult,
+} from "../../types";
 import { Table } from "../../components/Table";
 import { Button } from "../../components/Button";
 import { Input } from "../../components/Input";
 import { Modal } from "../../components/Modal";
 import { Spoiler } from "../../components/Spoiler";
 
+const generators: { value: GeneratorType | ""; label: string }[] = [
+	{ value: "", label: "Enter manually" },
+	{ value: "password", label: "Password" },
+	{ value: "hex", label: "Random hex" },
+	{ value: "uuid", label: "UUID" },
+	{ value: "ed25519", label: "Ed25519 keypair" },
+	{ value: "rsa", label: "RSA keypair" },
+];
+
 interface SecretsPanelProps {
 	showToast: (message: string, type: "success" | "error" | "info") => void;
 }
@@ -27,8 +45,28 @@ export function SecretsPanel({ showToast }: SecretsPanelProps) {
 	const [createOpen, setCreateOpen] = useState(false);
 	const [createKey, setCreateKey] = useState("");
 	const [createValue, setCreateValue] = useState("");
+	const [createGenerator, setCreateGenerator] = useState<GeneratorType | "">(
+		""
+	);
 	const [createLoading, setCreateLoading] = useState(false);
 
+	const [importOpen, setImportOpen] = useState(false);
+	const [importContent, setImportContent] = useState("");
+	const [importFormat, setImportFormat] = useState<ImportFormat>("env");
+	const [importConflict, setImportConflict] = useState<ConflictPolicy>("skip");
+	const [importPrefix, setImportPrefix] = useState("");
+	const [importPreview, setImportPreview] = useState<ImportResult | null>(
+		null
+	);
+	const [importLoading, setImportLoading] = useState(false);
+
+	const [exportOpen, setExportOpen] = useState(false);
+	const [exportFormat, setExportFormat] = useState<ExportFormat>("env");
+	const [exportPrefix, setExportPrefix] = useState("");
+	const [exportStripPrefix, setExportStripPrefix] = useState(true);
+	const [exportEnvNames, setExportEnvNames] = useState(true);
+	const [exportLoading, setExportLoading] = useState(false);
+
 	const [editOpen, setEditOpen] = useState(false);
 	const [editKey, setEditKey] = useState("");
 	const [editValue, setEditValue] = useState("");
@@ -56,11 +94,16 @@ export function SecretsPanel({ showToast }: SecretsPanelProps) {
 		e.preventDefault();
 		setCreateLoading(true);
 		try {
-			await api.secrets.create(createKey, createValue);
+			await api.secrets.create(
+				createKey,
+				createGenerator ? "" : createValue,
+				createGenerator ? { type: createGenerator } : undefined
+			);
 			showToast("Secret created", "success");
 			setCreateOpen(false);
 			setCreateKey("");
 			setCreateValue("");
+			setCreateGenerator("");
 			load();
 		} catch (err) {
 			showToast(
@@ -72,6 +115,89 @@ export function SecretsPanel({ showToast }: SecretsPanelProps) {
 		}
 	};
 
+	const openImport = () => {
+		setImportContent("");
+		setImportPrefix("");
+		setImportPreview(null);
+		setImportOpen(true);
+	};
+
+	const handleImportFile = async (file: File) => {
+		const name = file.name.toLowerCase();
+		if (name.endsWith(".json")) setImportFormat("json");
+		else if (name.endsWith(".yaml") || name.endsWith(".yml"))
+			setImportFormat("yaml");
+		else setImportFormat("env");
+		setImportContent(await file.text());
+		setImportPreview(null);
+	};
+
+	const handleExport = async (e: FormEvent) => {
+		e.preventDefault();
+		setExportLoading(true);
+		try {
+			const content = await api.secrets.export(
+				exportFormat,
+				exportPrefix,
+				exportStripPrefix,
+				exportEnvNames
+			);
+			const filename = {
+				env: ".env",
+				json: "secrets.json",
+				yaml: "secrets.yaml",
+				shell: "secrets.sh",
+			}[exportFormat];
+			const url = URL.createObjectURL(new Blob([content]));
+			const link = document.createElement("a");
+			link.href = url;
+			link.download = filename;
+			link.click();
+			URL.revokeObjectURL(url);
+			setExportOpen(false);
+		} catch (err) {
+			showToast(
+				err instanceof Error ? err.message : "Failed to export secrets",
+				"error"
+			);
+		} finally {
+			setExportLoading(false);
+		}
+	};
+
+	// the first submit previews the changes with a dry run, the second one imports them
+	const handleImport = async (e: FormEvent) => {
+		e.preventDefault();
+		setImportLoading(true);
+		try {
+			const dryRun = importPreview === null;
+			const result = await api.secrets.import(
+				importContent,
+				importFormat,
+				importConflict,
+				dryRun,
+				importPrefix
+			);
+			if (dryRun) {
+				setImportPreview(result);
+				return;
+			}
+			const written = result.changes.filter(
+				(c) => c.action === "create" || c.action === "overwrite"
+			).length;
+			showToast(`Imported ${written} secrets`, "success");
+			setImportOpen(false);
+			load();
+		} catch (err) {
+			showToast(
+				err instanceof Error ? err.message : "Failed to import secrets",
+				"error"
+			);
+		} finally {
+			setImportLoading(false);
+		}
+	};
+
 	const handleEdit = async (e: FormEvent) => {
 		e.preventDefault();
 		setEditLoading(true);
@@ -191,10 +317,20 @@ export function SecretsPanel({ showToast }: SecretsPanelProps) {
 						className="pl-9 pr-4 py-2 rounded-lg bg-slate-800 border border-slate-700 text-sm text-slate-200 placeholder:text-slate-500 outline-none focus:border-sky-500 w-64"
 					/>
 				</div>
-				<Button onClick={() => setCreateOpen(true)}>
-					<Plus size={16} />
-					New Secret
-				</Button>
+				<div className="flex gap-2">
+					<Button variant="secondary" onClick={openImport}>
+						<Upload size={16} />
+						Import
+					</Button>
+					<Button variant="secondary" onClick={() => setExportOpen(true)}>
+						<Download size={16} />
+						Export
+					</Button>
+					<Button onClick={() => setCreateOpen(true)}>
+						<Plus size={16} />
+						New Secret
+					</Button>
+				</div>
 			</div>
 
 			{loading ? (
@@ -222,26 +358,212 @@ export function SecretsPanel({ showToast }: SecretsPanelProps) {
 						placeholder="e.g. DATABASE_URL"
 						required
 					/>
+					<div className="flex flex-col gap-1.5">
+						<label className="text-xs font-medium text-slate-400 uppercase tracking-wide">
+							Value Source
+						</label>
+						<select
+							value={createGenerator}
+							onChange={(e) =>
+								setCreateGenerator(e.target.value as GeneratorType | "")
+							}
+							className="w-full px-3.5 py-2.5 rounded-lg bg-slate-800 border border-slate-600 text-slate-100 outline-none focus:border-sky-500"
+						>
+							{generators.map((g) => (
+								<option key={g.value} value={g.value}>
+									{g.label}
+								</option>
+							))}
+						</select>
+					</div>
+					{createGenerator === "" && (
+						<Input
+							id="create-value"
+							label="Value"
+							multiline
+							value={createValue}
+							onChange={(e) => setCreateValue(e.target.value)}
+							placeholder="Secret value"
+							required
+						/>
+					)}
+					<div className="flex gap-3 mt-2">
+						<Button
+							variant="secondary"
+							type="button"
+							onClick={() => setCreateOpen(false)}
+							className="flex-1"
+						>
+							Cancel
+						</Button>
+						<Button type="submit" loading={createLoading} className="flex-1">
+							Create
+						</Button>
+					</div>
+				</form>
+			</Modal>
+
+			<Modal
+				open={importOpen}
+				onClose={() => setImportOpen(false)}
+				title="Import Secrets"
+			>
+				<form onSubmit={handleImport} className="flex flex-col gap-4">
+					<input
+						type="file"
+						accept=".env,.json,.yaml,.yml,text/plain"
+						onChange={(e) =>
+							e.target.files?.[0] && handleImportFile(e.target.files[0])
+						}
+						className="text-sm text-slate-400"
+					/>
 					<Input
-						id="create-value"
-						label="Value"
+						id="import-content"
+						label="Content"
 						multiline
-						value={createValue}
-						onChange={(e) => setCreateValue(e.target.value)}
-						placeholder="Secret value"
+						value={importContent}
+						onChange={(e) => {
+							setImportContent(e.target.value);
+							setImportPreview(null);
+						}}
+						placeholder="KEY=value"
 						required
 					/>
+					<div className="flex gap-3">
+						<div className="flex flex-col gap-1.5 flex-1">
+							<label className="text-xs font-medium text-slate-400 uppercase tracking-wide">
+								Format
+							</label>
+							<select
+								value={importFormat}
+								onChange={(e) => {
+									setImportFormat(e.target.value as ImportFormat);
+									setImportPreview(null);
+								}}
+								className="w-full px-3.5 py-2.5 rounded-lg bg-slate-800 border border-slate-600 text-slate-100 outline-none focus:border-sky-500"
+							>
+								<option value="env">.env</option>
+								<option value="json">JSON</option>
+								<option value="yaml">YAML</option>
+							</select>
+						</div>
+						<div className="flex flex-col gap-1.5 flex-1">
+							<label className="text-xs font-medium text-slate-400 uppercase tracking-wide">
+								Existing Keys
+							</label>
+							<select
+								value={importConflict}
+								onChange={(e) => {
+									setImportConflict(e.target.value as ConflictPolicy);
+									setImportPreview(null);
+								}}
+								className="w-full px-3.5 py-2.5 rounded-lg bg-slate-800 border border-slate-600 text-slate-100 outline-none focus:border-sky-500"
+							>
+								<option value="skip">Skip</option>
+								<option value="overwrite">Overwrite</option>
+								<option value="fail">Fail</option>
+							</select>
+						</div>
+					</div>
+					<Input
+						id="import-prefix"
+						label="Key Prefix (optional)"
+						value={importPrefix}
+						onChange={(e) => {
+							setImportPrefix(e.target.value);
+							setImportPreview(null);
+						}}
+						placeholder="e.g. app/"
+					/>
+					{importPreview && (
+						<div className="max-h-48 overflow-auto rounded-lg border border-slate-700 bg-slate-900 p-3 font-mono text-xs">
+							{importPreview.changes.map((c) => (
+								<div
+									key={c.key}
+									className={
+										c.action === "create"
+											? "text-emerald-400"
+											: c.action === "overwrite"
+												? "text-amber-400"
+												: "text-slate-500"
+									}
+								>
+									{c.action.padEnd(10)} {c.key}
+								</div>
+							))}
+						</div>
+					)}
 					<div className="flex gap-3 mt-2">
 						<Button
 							variant="secondary"
 							type="button"
-							onClick={() => setCreateOpen(false)}
+							onClick={() => setImportOpen(false)}
 							className="flex-1"
 						>
 							Cancel
 						</Button>
-						<Button type="submit" loading={createLoading} className="flex-1">
-							Create
+						<Button type="submit" loading={importLoading} className="flex-1">
+							{importPreview ? "Import" : "Preview"}
+						</Button>
+					</div>
+				</form>
+			</Modal>
+
+			<Modal
+				open={exportOpen}
+				onClose={() => setExportOpen(false)}
+				title="Export Secrets"
+			>
+				<form onSubmit={handleExport} className="flex flex-col gap-4">
+					<div className="flex flex-col gap-1.5">
+						<label className="text-xs font-medium text-slate-400 uppercase tracking-wide">
+							Format
+						</label>
+						<select
+							value={exportFormat}
+							onChange={(e) => setExportFormat(e.target.value as ExportFormat)}
+							className="w-full px-3.5 py-2.5 rounded-lg bg-slate-800 border border-slate-600 text-slate-100 outline-none focus:border-sky-500"
+						>
+							<option value="env">.env</option>
+							<option value="json">JSON</option>
+							<option value="yaml">YAML</option>
+							<option value="shell">Shell (export)</option>
+						</select>
+					</div>
+					<Input
+						id="export-prefix"
+						label="Key Prefix (optional)"
+						value={exportPrefix}
+						onChange={(e) => setExportPrefix(e.target.value)}
+						placeholder="e.g. app/"
+					/>
+					<label className="flex items-center gap-2 text-sm text-slate-300">
+						<input
+							type="checkbox"
+							checked={exportStripPrefix}
+							onChange={(e) => setExportStripPrefix(e.target.checked)}
+						/>
+						Strip the prefix from keys
+					</label>
+					<label className="flex items-center gap-2 text-sm text-slate-300">
+						<input
+							type="checkbox"
+							checked={exportEnvNames}
+							onChange={(e) => setExportEnvNames(e.target.checked)}
+						/>
+						Use env names (aws/access-key → AWS_ACCESS_KEY)
+					</label>
+					<div className="flex gap-3 mt-2">
+						<Button
+							variant="secondary"
+							type="button"
+							onClick={() => setExportOpen(false)}
+							className="flex-1"
+						>
+							Cancel
+						</Button>
+						<Button type="submit" loading={exportLoading} className="flex-1">
+							Download
 						</Button>
 					</div>
 				</form>
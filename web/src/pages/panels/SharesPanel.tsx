@@ -0,0 +1,223 @@
+import { useState, useEffect, FormEvent } from "react";
+import { Plus, Trash2, ClipboardCopy } from "lucide-react";
+import { api } from "../../api";
+import type { Share } from "../../types";
+import { Table } from "../../components/Table";
+import { Button } from "../../components/Button";
+import { Input } from "../../components/Input";
+import { Modal } from "../../components/Modal";
+
+interface SharesPanelProps {
+	showToast: (message: string, type: "success" | "error" | "info") => void;
+}
+
+export function SharesPanel({ showToast }: SharesPanelProps) {
+	const [shares, setShares] = useState<Share[]>([]);
+	const [loading, setLoading] = useState(true);
+
+	const [createOpen, setCreateOpen] = useState(false);
+	const [createValue, setCreateValue] = useState("");
+	const [createMaxViews, setCreateMaxViews] = useState("1");
+	const [createExpiresIn, setCreateExpiresIn] = useState("24h");
+	const [createLoading, setCreateLoading] = useState(false);
+	const [createdUrl, setCreatedUrl] = useState("");
+
+	const load = async () => {
+		try {
+			const data = await api.shares.list();
+			setShares(data);
+		} catch (err) {
+			showToast(
+				err instanceof Error ? err.message : "Failed to load shares",
+				"error"
+			);
+		} finally {
+			setLoading(false);
+		}
+	};
+
+	useEffect(() => {
+		load();
+	}, []);
+
+	const openCreate = () => {
+		setCreateValue("");
+		setCreateMaxViews("1");
+		setCreateExpiresIn("24h");
+		setCreatedUrl("");
+		setCreateOpen(true);
+	};
+
+	const handleCreate = async (e: FormEvent) => {
+		e.preventDefault();
+		setCreateLoading(true);
+		try {
+			const share = await api.shares.create(
+				createValue,
+				Number(createMaxViews),
+				createExpiresIn
+			);
+			// relative unless the server has a public url
+			setCreatedUrl(new URL(share.url, window.location.origin).toString());
+			setCreateValue("");
+			load();
+		} catch (err) {
+			showToast(
+				err instanceof Error ? err.message : "Failed to create share",
+				"error"
+			);
+		} finally {
+			setCreateLoading(false);
+		}
+	};
+
+	const handleDelete = async (id: string) => {
+		if (!confirm("Burn this share?")) return;
+		try {
+			await api.shares.delete(id);
+			showToast("Share burnt", "success");
+			load();
+		} catch (err) {
+			showToast(
+				err instanceof Error ? err.message : "Failed to delete share",
+				"error"
+			);
+		}
+	};
+
+	const copyUrl = () => {
+		navigator.clipboard
+			.writeText(createdUrl)
+			.then(() => showToast("Link copied", "success"));
+	};
+
+	const columns = [
+		{
+			key: "id",
+			header: "Share",
+			render: (s: Share) => (
+				<span className="font-mono text-slate-300">{s.id.slice(0, 8)}</span>
+			),
+		},
+		{
+			key: "views",
+			header: "Views",
+			render: (s: Share) => (
+				<span className="text-slate-400">
+					{s.views} / {s.max_views}
+				</span>
+			),
+		},
+		{
+			key: "expires",
+			header: "Expires",
+			render: (s: Share) => (
+				<span className="text-slate-400">
+					{new Date(s.expires_at).toLocaleString()}
+				</span>
+			),
+		},
+		{
+			key: "actions",
+			header: "",
+			className: "text-right w-1",
+			render: (s: Share) => (
+				<Button
+					variant="ghost"
+					size="sm"
+					onClick={() => handleDelete(s.id)}
+					title="Burn"
+					className="text-red-400 hover:text-red-300"
+				>
+					<Trash2 size={14} />
+				</Button>
+			),
+		},
+	];
+
+	return (
+		<div>
+			<div className="flex items-center justify-end mb-4">
+				<Button onClick={openCreate}>
+					<Plus size={16} />
+					New Share
+				</Button>
+			</div>
+
+			{loading ? (
+				<div className="text-slate-500 py-12 text-center">Loading...</div>
+			) : (
+				<Table
+					columns={columns}
+					data={shares}
+					keyField="id"
+					emptyMessage="No active shares"
+				/>
+			)}
+
+			<Modal
+				open={createOpen}
+				onClose={() => setCreateOpen(false)}
+				title="New Share"
+			>
+				{createdUrl ? (
+					<div className="flex flex-col gap-4">
+						<p className="text-sm text-slate-400">
+							The link contains the decryption key and is shown only once.
+						</p>
+						<code className="font-mono text-xs text-sky-400 bg-slate-900 border border-slate-700 rounded-lg p-3 break-all">
+							{createdUrl}
+						</code>
+						<Button onClick={copyUrl} className="w-full">
+							<ClipboardCopy size={16} />
+							Copy Link
+						</Button>
+					</div>
+				) : (
+					<form onSubmit={handleCreate} className="flex flex-col gap-4">
+						<Input
+							id="create-value"
+							label="Value"
+							multiline
+							value={createValue}
+							onChange={(e) => setCreateValue(e.target.value)}
+							placeholder="Secret to share"
+							required
+						/>
+						<Input
+							id="create-max-views"
+							label="Max Views"
+							type="number"
+							min={1}
+							max={100}
+							value={createMaxViews}
+							onChange={(e) => setCreateMaxViews(e.target.value)}
+							required
+						/>
+						<Input
+							id="create-expires-in"
+							label="Expires In"
+							value={createExpiresIn}
+							onChange={(e) => setCreateExpiresIn(e.target.value)}
+							placeholder="e.g. 24h"
+							required
+						/>
+						<div className="flex gap-3 mt-2">
+							<Button
+								variant="secondary"
+								type="button"
+								onClick={() => setCreateOpen(false)}
+								className="flex-1"
+							>
+								Cancel
+							</Button>
+							<Button type="submit" loading={createLoading} className="flex-1">
+								Create
+							</Button>
+						</div>
+					</form>
+				)}
+			</Modal>
+		</div>
+	);
+}
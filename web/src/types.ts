@@ -2,10 +2,28 @@ export interface Secret {
 	id: string;
 	key: string;
 	value: string;
+	expires_at: string | null;
+	rotate_by: string | null;
 	created_at: string;
 	updated_at: string;
 }
 
+export type GeneratorType =
+	| "password"
+	| "bytes"
+	| "hex"
+	| "uuid"
+	| "rsa"
+	| "ed25519";
+
+export interface GeneratorOptions {
+	type: GeneratorType;
+	length?: number;
+	charset?: string;
+	encoding?: "hex" | "base64";
+	bits?: number;
+}
+
 export interface User {
 	id: string;
 	username: string;
@@ -30,6 +48,37 @@ export interface Permission {
 	updated_at: string;
 }
 
+export type ImportFormat = "env" | "json" | "yaml";
+
+export type ExportFormat = ImportFormat | "shell";
+
+export type ConflictPolicy = "skip" | "overwrite" | "fail";
+
+export interface ImportResult {
+	dry_run: boolean;
+	changes: { key: string; action: "create" | "overwrite" | "skip" | "unchanged" }[];
+}
+
+export interface Share {
+	id: string;
+	created_at: string;
+	created_by: string;
+	max_views: number;
+	views: number;
+	expires_at: string;
+}
+
+export interface CreatedShare extends Share {
+	url: string;
+}
+
+export interface RevealedShare {
+	ciphertext: string;
+	nonce: string;
+	views: number;
+	max_views: number;
+}
+
 export interface ApiResponse<T> {
 	message: string;
 	data: T;